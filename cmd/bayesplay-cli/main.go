@@ -0,0 +1,205 @@
+// Command bayesplay-cli computes Bayes factors from the command line.
+//
+// Models are given as "name:p1,p2,..." definitions using the same names
+// and parameter order as bayesfactor.LikelihoodDefinition and
+// bayesfactor.PriorDefinition, for example:
+//
+//	bayesplay-cli -likelihood noncentral_d:0.227,80 \
+//		-alt cauchy:0,0.707,-Inf,Inf -null point:0
+//
+// The definitions can also be read from a spec file with -spec, either in
+// the "key = definition" format or as a JSON model spec. Flags given on the
+// command line override the values in the spec file. Use -print-spec to
+// save the model as a JSON model spec.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"pkg/bayesfactor"
+)
+
+// summary is the output of a single Bayes factor computation. Bayes
+// factors that aren't finite are encoded as null in JSON.
+type summary struct {
+	BF10         float64 `json:"bf10"`
+	BF01         float64 `json:"bf01"`
+	LogBF10      float64 `json:"logbf10"`
+	AltMarginal  float64 `json:"altMarginal"`
+	NullMarginal float64 `json:"nullMarginal"`
+
+	Posterior    *bayesfactor.PosteriorSummary  `json:"posterior,omitempty"`
+	SavageDickey *bayesfactor.SavageDickeyCheck `json:"savageDickey,omitempty"`
+}
+
+// MarshalJSON encodes the summary, with null in place of Bayes factors
+// that aren't finite
+func (s summary) MarshalJSON() ([]byte, error) {
+	type plain summary
+	return json.Marshal(struct {
+		plain
+		BF10    *float64 `json:"bf10"`
+		BF01    *float64 `json:"bf01"`
+		LogBF10 *float64 `json:"logbf10"`
+	}{plain(s), bayesfactor.Finite(s.BF10), bayesfactor.Finite(s.BF01), bayesfactor.Finite(s.LogBF10)})
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout io.Writer, stderr io.Writer) int {
+
+	flags := flag.NewFlagSet("bayesplay-cli", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	specFile := flags.String("spec", "", "read the model from a spec `file`")
+	likelihood := flags.String("likelihood", "", "likelihood `definition`, e.g. normal:5.5,32.35")
+	alt := flags.String("alt", "", "alternative prior `definition`, e.g. cauchy:0,0.707,-Inf,Inf")
+	null := flags.String("null", "", "null prior `definition`, e.g. point:0")
+	asJSON := flags.Bool("json", false, "print the results as JSON")
+	printSpec := flags.Bool("print-spec", false, "print the model as a JSON model spec and exit")
+	posterior := flags.Bool("posterior", false, "also summarise the posterior under the alternative prior")
+	level := flags.Float64("level", 0.95, "`level` of the posterior credible intervals")
+	numeric := flags.Bool("numeric", false, "always integrate numerically, even when there is a closed form")
+	check := flags.Bool("check", false, "cross-check BF01 against the Savage-Dickey density ratio (point nulls only)")
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	var m model
+	if *specFile != "" {
+		f, err := os.Open(*specFile)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 2
+		}
+		err = readSpec(f, &m)
+		f.Close()
+		if err != nil {
+			fmt.Fprintf(stderr, "%s: %v\n", *specFile, err)
+			return 2
+		}
+	}
+
+	overrides := []struct {
+		key   string
+		value string
+	}{{"likelihood", *likelihood}, {"alt", *alt}, {"null", *null}}
+	for _, o := range overrides {
+		if o.value == "" {
+			continue
+		}
+		if err := m.set(o.key, o.value); err != nil {
+			fmt.Fprintf(stderr, "-%s: %v\n", o.key, err)
+			return 2
+		}
+	}
+
+	if m.likelihood.Name == "" || m.altprior.Name == "" || m.nullprior.Name == "" {
+		fmt.Fprintln(stderr, "a likelihood, an alternative prior and a null prior are required")
+		flags.Usage()
+		return 2
+	}
+
+	if *printSpec {
+		spec := bayesfactor.NewModelSpec(m.likelihood, m.altprior, m.nullprior)
+		if err := spec.Validate(); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		if err := writeJSON(stdout, spec); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		return 0
+	}
+
+	opts := bayesfactor.DefaultOptions
+	opts.Numeric = *numeric
+	result, altModel, err := compute(m, opts)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	if *posterior {
+		post, err := bayesfactor.NewPosterior(altModel)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		summary := post.Summary(*level)
+		result.Posterior = &summary
+	}
+
+	if *check {
+		if m.nullprior.Name != "point" {
+			fmt.Fprintln(stderr, "-check requires a point null prior")
+			return 2
+		}
+		sd, err := bayesfactor.CrossCheckSavageDickey(m.likelihood, m.altprior, m.nullprior.Params[0])
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		result.SavageDickey = &sd
+	}
+
+	if *asJSON {
+		if err := writeJSON(stdout, result); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		return 0
+	}
+
+	fmt.Fprintf(stdout, "%-27s%g\n", "BF10", result.BF10)
+	fmt.Fprintf(stdout, "%-27s%g\n", "BF01", result.BF01)
+	fmt.Fprintf(stdout, "%-27s%g\n", "log BF10", result.LogBF10)
+	fmt.Fprintf(stdout, "%-27s%g\n", "marginal likelihood (alt)", result.AltMarginal)
+	fmt.Fprintf(stdout, "%-27s%g\n", "marginal likelihood (null)", result.NullMarginal)
+	if p := result.Posterior; p != nil {
+		fmt.Fprintf(stdout, "%-27s%g\n", "posterior mean", p.Mean)
+		fmt.Fprintf(stdout, "%-27s%g\n", "posterior median", p.Median)
+		fmt.Fprintf(stdout, "%-27s%g\n", "posterior mode", p.Mode)
+		fmt.Fprintf(stdout, "%-27s%g\n", "posterior sd", p.SD)
+		fmt.Fprintf(stdout, "%-27s[%g, %g]\n", fmt.Sprintf("%g%% equal-tailed interval", 100*p.Level), p.ETI[0], p.ETI[1])
+		fmt.Fprintf(stdout, "%-27s[%g, %g]\n", fmt.Sprintf("%g%% HDI", 100*p.Level), p.HDI[0], p.HDI[1])
+	}
+	if c := result.SavageDickey; c != nil {
+		fmt.Fprintf(stdout, "%-27s%g\n", "BF01 (Savage-Dickey)", c.SavageDickey)
+		fmt.Fprintf(stdout, "%-27s%g\n", "relative difference", c.RelDiff)
+	}
+	return 0
+}
+
+// compute computes the Bayes factor from the marginal likelihoods of the
+// two models, and returns the predictive of the alternative model so that
+// the posterior doesn't need to be computed again
+func compute(m model, opts bayesfactor.Options) (summary, bayesfactor.Predictive, error) {
+
+	var result summary
+
+	comparison, err := bayesfactor.CompareWithOptions(m.likelihood, m.altprior, m.nullprior, opts)
+	if err != nil {
+		return result, comparison.Alt, err
+	}
+
+	result.BF10 = comparison.BF10()
+	result.BF01 = comparison.BF01()
+	result.LogBF10 = comparison.LogBF10
+	result.AltMarginal = comparison.Alt.Auc
+	result.NullMarginal = comparison.Null.Auc
+	return result, comparison.Alt, nil
+}
+
+func writeJSON(w io.Writer, value interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// execute runs the command with args and returns its exit code, stdout
+// and stderr
+func execute(args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+// values parses the "label value" lines of the text output
+func values(t *testing.T, output string) map[string]float64 {
+	t.Helper()
+	result := make(map[string]float64)
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		if len(line) < 27 || strings.HasPrefix(line[27:], "[") {
+			continue
+		}
+		value, err := strconv.ParseFloat(line[27:], 64)
+		if err != nil {
+			t.Fatalf("could not parse %q: %v", line, err)
+		}
+		result[strings.TrimSpace(line[:27])] = value
+	}
+	return result
+}
+
+func expectBF(t *testing.T, got map[string]float64, want float64) {
+	t.Helper()
+	if math.Abs(got["BF10"]-want) > 0.001 {
+		t.Fatalf("got BF10 %v, wanted %v", got["BF10"], want)
+	}
+	if math.Abs(got["BF01"]-1/want) > 0.001 {
+		t.Fatalf("got BF01 %v, wanted %v", got["BF01"], 1/want)
+	}
+	if math.Abs(got["log BF10"]-math.Log(want)) > 0.001 {
+		t.Fatalf("got log BF10 %v, wanted %v", got["log BF10"], math.Log(want))
+	}
+}
+
+func writeFile(t *testing.T, name string, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRunFlags(t *testing.T) {
+
+	code, stdout, stderr := execute(
+		"-likelihood", "normal:5.5,32.35",
+		"-alt", "normal:0,13.3,0,Inf",
+		"-null", "point:0",
+	)
+	if code != 0 {
+		t.Fatalf("got exit code %v: %s", code, stderr)
+	}
+	got := values(t, stdout)
+	expectBF(t, got, 0.9745934)
+	if math.Abs(got["marginal likelihood (alt)"]/got["marginal likelihood (null)"]-got["BF10"]) > 1e-6 {
+		t.Fatalf("got marginals %v, wanted a ratio of %v", got, got["BF10"])
+	}
+}
+
+func TestRunSpecFile(t *testing.T) {
+
+	spec := writeFile(t, "model.txt", `# Dienes & Mclatchie (2018)
+likelihood = normal:5.5,32.35
+alt = normal:0,13.3,0,Inf
+null = point:0
+`)
+	code, stdout, stderr := execute("-spec", spec)
+	if code != 0 {
+		t.Fatalf("got exit code %v: %s", code, stderr)
+	}
+	expectBF(t, values(t, stdout), 0.9745934)
+
+	// flags override the spec file
+	code, stdout, stderr = execute("-spec", spec, "-alt", "normal:0,13.3,-Inf,Inf")
+	if code != 0 {
+		t.Fatalf("got exit code %v: %s", code, stderr)
+	}
+	twoSided := values(t, stdout)["BF10"]
+	if !(twoSided < 0.9745934) {
+		t.Fatalf("got two sided BF10 %v, wanted less than the one sided BF10", twoSided)
+	}
+
+	// the printed JSON spec can be read back in
+	code, stdout, stderr = execute("-spec", spec, "-print-spec")
+	if code != 0 {
+		t.Fatalf("got exit code %v: %s", code, stderr)
+	}
+	code, stdout, stderr = execute("-spec", writeFile(t, "model.json", stdout))
+	if code != 0 {
+		t.Fatalf("got exit code %v: %s", code, stderr)
+	}
+	expectBF(t, values(t, stdout), 0.9745934)
+}
+
+func TestRunPosterior(t *testing.T) {
+
+	args := []string{
+		"-likelihood", "normal:5.5,32.35",
+		"-alt", "normal:0,13.3,-Inf,Inf",
+		"-null", "point:0",
+		"-posterior", "-json",
+	}
+
+	// the posterior is the same with the closed form and numerically
+	var closed, numeric summary
+	for _, c := range []struct {
+		args   []string
+		result *summary
+	}{{args, &closed}, {append(args, "-numeric"), &numeric}} {
+		code, stdout, stderr := execute(c.args...)
+		if code != 0 {
+			t.Fatalf("got exit code %v: %s", code, stderr)
+		}
+		if err := json.Unmarshal([]byte(stdout), c.result); err != nil {
+			t.Fatalf("could not decode %s: %v", stdout, err)
+		}
+		if c.result.Posterior == nil {
+			t.Fatalf("got no posterior in %s", stdout)
+		}
+	}
+	if math.Abs(closed.Posterior.Mean-numeric.Posterior.Mean) > 1e-4 || math.Abs(closed.BF10-numeric.BF10) > 1e-4 {
+		t.Fatalf("got %+v and %+v, wanted the same posterior", closed.Posterior, numeric.Posterior)
+	}
+}
+
+func TestRunLargeBayesfactor(t *testing.T) {
+
+	args := []string{
+		"-likelihood", "noncentral_d:1.5,2000",
+		"-alt", "cauchy:0,0.707,-Inf,Inf",
+		"-null", "point:0",
+	}
+
+	code, stdout, stderr := execute(args...)
+	if code != 0 {
+		t.Fatalf("got exit code %v: %s", code, stderr)
+	}
+	got := values(t, stdout)
+	if !math.IsInf(got["BF10"], 1) || got["BF01"] != 0 || math.Abs(got["log BF10"]-1173.6) > 0.1 {
+		t.Fatalf("got %v, wanted an infinite BF10 and a log BF10 of 1173.6", got)
+	}
+
+	// the infinite BF10 is null in JSON
+	code, stdout, stderr = execute(append(args, "-json")...)
+	if code != 0 {
+		t.Fatalf("got exit code %v: %s", code, stderr)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal([]byte(stdout), &decoded); err != nil {
+		t.Fatalf("could not decode %s: %v", stdout, err)
+	}
+	if bf, ok := decoded["bf10"]; !ok || bf != nil {
+		t.Fatalf("got bf10 %v, wanted null", bf)
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+
+	cases := []struct {
+		name string
+		args []string
+		code int
+	}{
+		{"unknown flag", []string{"-bogus"}, 2},
+		{"missing definitions", []string{"-likelihood", "normal:5.5,32.35"}, 2},
+		{"bad parameter", []string{"-likelihood", "normal:5.5,x", "-alt", "normal:0,1", "-null", "point:0"}, 2},
+		{"missing spec file", []string{"-spec", filepath.Join(t.TempDir(), "missing.txt")}, 2},
+		{"bad spec file", []string{"-spec", writeFile(t, "bad.txt", "likelihood normal:5.5,32.35\n")}, 2},
+		{"check without a point null", []string{"-likelihood", "normal:5.5,32.35", "-alt", "normal:0,13.3,-Inf,Inf", "-null", "uniform:-1,1", "-check"}, 2},
+		{"invalid model", []string{"-likelihood", "normal:5.5,-1", "-alt", "normal:0,13.3,-Inf,Inf", "-null", "point:0"}, 1},
+		{"unknown family", []string{"-likelihood", "weibull:1,2", "-alt", "normal:0,13.3,-Inf,Inf", "-null", "point:0"}, 1},
+	}
+	for _, c := range cases {
+		code, stdout, stderr := execute(c.args...)
+		if code != c.code {
+			t.Fatalf("%s: got exit code %v, wanted %v", c.name, code, c.code)
+		}
+		if stdout != "" || stderr == "" {
+			t.Fatalf("%s: got stdout %q and stderr %q, wanted only an error", c.name, stdout, stderr)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"pkg/bayesfactor"
+)
+
+// model holds the three definitions needed to compute a Bayes factor
+type model struct {
+	likelihood bayesfactor.LikelihoodDefinition
+	altprior   bayesfactor.PriorDefinition
+	nullprior  bayesfactor.PriorDefinition
+}
+
+// parseDefinition parses a definition of the form "name:p1,p2,..."
+// (e.g. "cauchy:0,0.707,-Inf,Inf") into a name and its parameters
+func parseDefinition(def string) (string, []float64, error) {
+
+	parts := strings.SplitN(def, ":", 2)
+	name := strings.TrimSpace(parts[0])
+	if name == "" {
+		return "", nil, fmt.Errorf("missing distribution name in %q", def)
+	}
+
+	var params []float64
+	if len(parts) == 2 && strings.TrimSpace(parts[1]) != "" {
+		for _, field := range strings.Split(parts[1], ",") {
+			value, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+			if err != nil {
+				return "", nil, fmt.Errorf("invalid parameter %q in %q", strings.TrimSpace(field), def)
+			}
+			params = append(params, value)
+		}
+	}
+
+	return name, params, nil
+}
+
+// set parses def and stores it in the field named by key
+func (m *model) set(key string, def string) error {
+
+	name, params, err := parseDefinition(def)
+	if err != nil {
+		return err
+	}
+
+	switch key {
+	case "likelihood":
+		m.likelihood = bayesfactor.LikelihoodDefinition{Name: name, Params: params}
+	case "alt":
+		m.altprior = bayesfactor.PriorDefinition{Name: name, Params: params}
+	case "null":
+		m.nullprior = bayesfactor.PriorDefinition{Name: name, Params: params}
+	default:
+		return fmt.Errorf("unknown key %q", key)
+	}
+	return nil
+}
+
+// readSpec reads a spec file. This is either a JSON model spec (see
+// bayesfactor.ModelSpec) or a file made up of "key = definition" lines
+// where key is one of likelihood, alt or null. Blank lines and lines
+// starting with # are ignored.
+func readSpec(r io.Reader, m *model) error {
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		spec, err := bayesfactor.ParseModelSpec(data)
+		if err != nil {
+			return err
+		}
+		m.likelihood = spec.Likelihood
+		m.altprior = spec.AltPrior
+		m.nullprior = spec.NullPrior
+		return nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("line %d: expected key = definition", lineNumber)
+		}
+		if err := m.set(strings.TrimSpace(parts[0]), parts[1]); err != nil {
+			return fmt.Errorf("line %d: %v", lineNumber, err)
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,42 @@
+// Command bayesplay-server serves a JSON API for computing Bayes factors.
+//
+// All endpoints accept a POST request with a JSON model spec (see
+// bayesfactor.ModelSpec), which is the same payload as the computeAll WASM
+// function:
+//
+//	POST /bayesfactor  the Bayes factor and marginal likelihoods
+//	POST /compute      the full computeAll result (see analysis.Result)
+//	POST /posterior    the posterior for the alternative and null priors
+//
+// Errors are returned as {"error": {"code": "...", "message": "..."}}.
+// Requests are rejected with a 503 "busy" error while -workers
+// computations are already running.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"runtime"
+	"time"
+)
+
+func main() {
+
+	addr := flag.String("addr", ":8080", "address to listen on")
+	timeout := flag.Duration("timeout", 30*time.Second, "maximum time to spend on each request")
+	workers := flag.Int("workers", runtime.NumCPU(), "maximum number of computations to run at once")
+	flag.Parse()
+	if *workers < 1 {
+		log.Fatal("-workers must be at least 1")
+	}
+
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           newServer(*timeout, *workers),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	log.Printf("listening on %s", *addr)
+	log.Fatal(srv.ListenAndServe())
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"pkg/analysis"
+	"pkg/bayesfactor"
+	"pkg/distributions"
+)
+
+// maxBodySize is the largest request body that will be read
+const maxBodySize = 1 << 20
+
+// apiError is the body of every error response
+type apiError struct {
+	Error struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// bayesfactorResponse is the body of a successful /bayesfactor response.
+// The Bayes factors overflow when the log Bayes factor is large, and are
+// null when they aren't finite.
+type bayesfactorResponse struct {
+	BF10         *float64 `json:"bf10"`
+	BF01         *float64 `json:"bf01"`
+	LogBF10      *float64 `json:"logbf10"`
+	AltMarginal  float64  `json:"altMarginal"`
+	NullMarginal float64  `json:"nullMarginal"`
+}
+
+// posteriorResponse is the body of a successful /posterior response
+type posteriorResponse struct {
+	Alt  analysis.PosteriorResult `json:"alt"`
+	Null analysis.PosteriorResult `json:"null"`
+}
+
+// errBusy is returned when all of the workers are busy
+var errBusy = errors.New("too many computations in progress, try again later")
+
+type server struct {
+	timeout time.Duration
+	workers chan struct{}
+}
+
+// newServer returns the handler for the API. At most workers computations
+// run at once, and requests are rejected with a 503 response while they
+// are all busy. Each computation is abandoned with a 504 response if it
+// takes longer than timeout, but it keeps its worker until it finishes.
+func newServer(timeout time.Duration, workers int) http.Handler {
+	s := &server{timeout: timeout, workers: make(chan struct{}, workers)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/bayesfactor", s.post(s.bayesfactor))
+	mux.HandleFunc("/compute", s.post(s.compute))
+	mux.HandleFunc("/posterior", s.post(s.posterior))
+	return mux
+}
+
+// post adapts a computation to an http.HandlerFunc that only accepts POST
+// requests and encodes the result or error as JSON
+func (s *server) post(fn func(ctx context.Context, body []byte) (interface{}, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "only POST requests are supported")
+			return
+		}
+
+		var body json.RawMessage
+		decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+		if err := decoder.Decode(&body); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_json", err.Error())
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), s.timeout)
+		defer cancel()
+
+		result, err := fn(ctx, body)
+		if err != nil {
+			status, code := classify(err)
+			writeError(w, status, code, err.Error())
+			return
+		}
+
+		data, err := json.Marshal(result)
+		if err != nil {
+			writeError(w, http.StatusUnprocessableEntity, "non_finite_result", "the result contains values that can't be represented in JSON")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	}
+}
+
+// classify maps an error to an HTTP status and an error code
+func classify(err error) (int, string) {
+
+	var modelErr *bayesfactor.ModelError
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout, "timeout"
+	case errors.Is(err, context.Canceled):
+		return http.StatusServiceUnavailable, "canceled"
+	case errors.Is(err, errBusy):
+		return http.StatusServiceUnavailable, "busy"
+	case errors.As(err, &modelErr), errors.Is(err, bayesfactor.ErrSpecVersion):
+		return http.StatusBadRequest, "invalid_model"
+	case errors.As(err, &syntaxErr), errors.As(err, &typeErr):
+		return http.StatusBadRequest, "invalid_json"
+	case errors.Is(err, bayesfactor.ErrUndefinedRatio):
+		return http.StatusUnprocessableEntity, "undefined"
+	case errors.Is(err, distributions.ErrTolerance), errors.Is(err, distributions.ErrIntegrand):
+		return http.StatusUnprocessableEntity, "integration_failed"
+	}
+	return http.StatusInternalServerError, "internal"
+}
+
+func writeError(w http.ResponseWriter, status int, code string, message string) {
+	var body apiError
+	body.Error.Code = code
+	body.Error.Message = message
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// run runs fn on a free worker and returns its result, or the context
+// error if the context is done first. The computations can't be
+// interrupted so fn keeps running, and holds on to its worker, in the
+// background after a timeout. errBusy is returned if there are no free
+// workers.
+func (s *server) run(ctx context.Context, fn func() (interface{}, error)) (interface{}, error) {
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	select {
+	case s.workers <- struct{}{}:
+	default:
+		return nil, errBusy
+	}
+
+	type outcome struct {
+		result interface{}
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		defer func() { <-s.workers }()
+		result, err := fn()
+		done <- outcome{result, err}
+	}()
+
+	select {
+	case o := <-done:
+		return o.result, o.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (s *server) bayesfactor(ctx context.Context, body []byte) (interface{}, error) {
+
+	spec, err := bayesfactor.ParseModelSpec(body)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.run(ctx, func() (interface{}, error) {
+		comparison, err := bayesfactor.Compare(spec.Likelihood, spec.AltPrior, spec.NullPrior)
+		if err != nil {
+			return nil, err
+		}
+		return bayesfactorResponse{
+			BF10:         bayesfactor.Finite(comparison.BF10()),
+			BF01:         bayesfactor.Finite(comparison.BF01()),
+			LogBF10:      bayesfactor.Finite(comparison.LogBF10),
+			AltMarginal:  comparison.Alt.Auc,
+			NullMarginal: comparison.Null.Auc,
+		}, nil
+	})
+}
+
+func (s *server) compute(ctx context.Context, body []byte) (interface{}, error) {
+
+	spec, err := bayesfactor.ParseModelSpec(body)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.run(ctx, func() (interface{}, error) {
+		return analysis.Compute(spec.Likelihood, spec.AltPrior, spec.NullPrior)
+	})
+}
+
+func (s *server) posterior(ctx context.Context, body []byte) (interface{}, error) {
+
+	spec, err := bayesfactor.ParseModelSpec(body)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.run(ctx, func() (interface{}, error) {
+		var response posteriorResponse
+		var err error
+		response.Alt, err = analysis.Posterior(spec.Likelihood, spec.AltPrior)
+		if err != nil {
+			return nil, err
+		}
+		response.Null, err = analysis.Posterior(spec.Likelihood, spec.NullPrior)
+		if err != nil {
+			return nil, err
+		}
+		return response, nil
+	})
+}
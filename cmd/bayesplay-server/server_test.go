@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"pkg/analysis"
+)
+
+const model = `{
+	"version": 1,
+	"likelihoodDef": {"distribution": "normal", "parameters": {"mean": 5.5, "sd": 32.35}},
+	"altpriorDef": {"distribution": "normal", "parameters": {"mean": 0, "sd": 13.3, "min": 0}},
+	"nullpriorDef": {"distribution": "point", "parameters": {"point": 0}}
+}`
+
+func post(t *testing.T, handler http.Handler, path string, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func decode(t *testing.T, rec *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	if err := json.NewDecoder(rec.Body).Decode(v); err != nil {
+		t.Fatalf("could not decode response: %v", err)
+	}
+}
+
+func expectError(t *testing.T, rec *httptest.ResponseRecorder, status int, code string) {
+	t.Helper()
+	if rec.Code != status {
+		t.Fatalf("got status %v, wanted %v", rec.Code, status)
+	}
+	var body apiError
+	decode(t, rec, &body)
+	if body.Error.Code != code || body.Error.Message == "" {
+		t.Fatalf("got error %+v, wanted code %q", body.Error, code)
+	}
+}
+
+func TestBayesfactorEndpoint(t *testing.T) {
+
+	handler := newServer(time.Minute, 4)
+	rec := post(t, handler, "/bayesfactor", model)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %v: %s", rec.Code, rec.Body)
+	}
+
+	var got bayesfactorResponse
+	decode(t, rec, &got)
+	if got.BF10 == nil || got.BF01 == nil || got.LogBF10 == nil {
+		t.Fatalf("got %+v, wanted finite Bayes factors", got)
+	}
+	if math.Abs(*got.BF10-0.9745934) > 0.001 {
+		t.Fatalf("got bf %v, wanted 0.9745934", *got.BF10)
+	}
+	if math.Abs(*got.BF10**got.BF01-1) > 1e-9 || math.Abs(math.Log(*got.BF10)-*got.LogBF10) > 1e-9 {
+		t.Fatalf("got BF10 %v, BF01 %v and log BF10 %v", *got.BF10, *got.BF01, *got.LogBF10)
+	}
+}
+
+func TestComputeEndpoint(t *testing.T) {
+
+	handler := newServer(time.Minute, 4)
+	rec := post(t, handler, "/compute", model)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %v: %s", rec.Code, rec.Body)
+	}
+
+	var got analysis.Result
+	decode(t, rec, &got)
+	if len(got.LikelihoodPlot) != 101 || len(got.Ratio) == 0 {
+		t.Fatalf("got incomplete result %+v", got)
+	}
+	if got.Names.Likelihood != "normal" || got.Names.Null != "point" {
+		t.Fatalf("got names %+v", got.Names)
+	}
+}
+
+func TestPosteriorEndpoint(t *testing.T) {
+
+	handler := newServer(time.Minute, 4)
+	rec := post(t, handler, "/posterior", model)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %v: %s", rec.Code, rec.Body)
+	}
+
+	var got posteriorResponse
+	decode(t, rec, &got)
+	if len(got.Alt.Plot) != 101 || len(got.Null.Plot) != 1 {
+		t.Fatalf("got %v and %v points", len(got.Alt.Plot), len(got.Null.Plot))
+	}
+	if math.Abs(got.Alt.Marginal/got.Null.Marginal-0.9745934) > 0.001 {
+		t.Fatalf("got marginals %v and %v", got.Alt.Marginal, got.Null.Marginal)
+	}
+}
+
+func TestLargeBayesfactor(t *testing.T) {
+
+	// the Bayes factor overflows a float64
+	large := `{
+		"likelihoodDef": {"distribution": "noncentral_d", "parameters": {"d": 1.5, "n": 2000}},
+		"altpriorDef": {"distribution": "cauchy", "parameters": {"location": 0, "scale": 0.707}},
+		"nullpriorDef": {"distribution": "point", "parameters": {"point": 0}}
+	}`
+	handler := newServer(time.Minute, 4)
+
+	rec := post(t, handler, "/bayesfactor", large)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %v: %s", rec.Code, rec.Body)
+	}
+	var got map[string]interface{}
+	decode(t, rec, &got)
+	// BF01 underflows to 0
+	if got["bf10"] != nil || got["bf01"] != 0.0 {
+		t.Fatalf("got %v, wanted a null BF10 and a BF01 of 0", got)
+	}
+	if logbf, _ := got["logbf10"].(float64); math.Abs(logbf-1173.6) > 0.1 {
+		t.Fatalf("got log bf %v, wanted 1173.6", got["logbf10"])
+	}
+
+	rec = post(t, handler, "/compute", large)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %v: %s", rec.Code, rec.Body)
+	}
+	got = nil
+	decode(t, rec, &got)
+	if got["bf"] != nil {
+		t.Fatalf("got bf %v, wanted null", got["bf"])
+	}
+	if logbf, _ := got["logbf"].(float64); math.Abs(logbf-1173.6) > 0.1 {
+		t.Fatalf("got log bf %v, wanted 1173.6", got["logbf"])
+	}
+}
+
+func TestErrors(t *testing.T) {
+
+	handler := newServer(time.Minute, 4)
+
+	// invalid json
+	rec := post(t, handler, "/bayesfactor", `{"likelihoodDef":`)
+	expectError(t, rec, http.StatusBadRequest, "invalid_json")
+
+	// invalid model
+	rec = post(t, handler, "/compute", strings.Replace(model, `"sd": 32.35`, `"sd": -1`, 1))
+	expectError(t, rec, http.StatusBadRequest, "invalid_model")
+
+	// unknown distribution
+	rec = post(t, handler, "/posterior", strings.Replace(model, `"distribution": "point"`, `"distribution": "spike"`, 1))
+	expectError(t, rec, http.StatusBadRequest, "invalid_model")
+
+	// wrong method
+	req := httptest.NewRequest(http.MethodGet, "/bayesfactor", nil)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	expectError(t, rec, http.StatusMethodNotAllowed, "method_not_allowed")
+	if rec.Header().Get("Allow") != http.MethodPost {
+		t.Fatalf("got Allow header %q", rec.Header().Get("Allow"))
+	}
+}
+
+func TestTimeout(t *testing.T) {
+
+	handler := newServer(time.Nanosecond, 4)
+	rec := post(t, handler, "/compute", model)
+	expectError(t, rec, http.StatusGatewayTimeout, "timeout")
+}
+
+func TestBusy(t *testing.T) {
+
+	s := &server{timeout: time.Minute, workers: make(chan struct{}, 1)}
+	release := make(chan struct{})
+	block := func() (interface{}, error) {
+		<-release
+		return nil, nil
+	}
+
+	// the abandoned computation keeps its worker
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := s.run(ctx, block); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got error %v, wanted %v", err, context.DeadlineExceeded)
+	}
+	if _, err := s.run(context.Background(), block); !errors.Is(err, errBusy) {
+		t.Fatalf("got error %v, wanted %v", err, errBusy)
+	}
+	if status, code := classify(errBusy); status != http.StatusServiceUnavailable || code != "busy" {
+		t.Fatalf("got status %v and code %q", status, code)
+	}
+
+	// and frees it once it finishes
+	close(release)
+	for len(s.workers) > 0 {
+		time.Sleep(time.Millisecond)
+	}
+	result, err := s.run(context.Background(), func() (interface{}, error) { return 1, nil })
+	if err != nil || result != 1 {
+		t.Fatalf("got %v and error %v", result, err)
+	}
+}
+
+func TestBusyEndpoint(t *testing.T) {
+
+	handler := newServer(time.Minute, 0)
+	rec := post(t, handler, "/bayesfactor", model)
+	expectError(t, rec, http.StatusServiceUnavailable, "busy")
+}
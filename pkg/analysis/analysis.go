@@ -0,0 +1,303 @@
+// Package analysis assembles the full set of results shown by the bayesplay
+// web app (Bayes factor, likelihood, prior and posterior curves, and the
+// comparison of model predictions) so that they can be produced outside of
+// the WASM bridge.
+package analysis
+
+import (
+	"encoding/json"
+	"math"
+
+	"pkg/bayesfactor"
+)
+
+// Limits is the x-axis range of a plot
+type Limits struct {
+	Xmin float64 `json:"xmin"`
+	Xmax float64 `json:"xmax"`
+}
+
+// Names holds the names of the likelihood and priors of a model
+type Names struct {
+	Likelihood string `json:"likelihoodName"`
+	Alt        string `json:"alt"`
+	Null       string `json:"null"`
+}
+
+// Prediction is the marginal likelihood of an observation under one of the
+// models
+type Prediction struct {
+	X    float64 `json:"x"`
+	Y    float64 `json:"y"`
+	Type string  `json:"type"`
+}
+
+// Model labels used in Prediction.Type
+const (
+	AltModel  = "Alternative model"
+	NullModel = "Null model"
+)
+
+// Result holds everything computed by Compute. The JSON field names match
+// the object returned by the computeAll WASM function, with the addition of
+// logbf. Bf overflows for very large Bayes factors, so LogBf should be
+// used for those; Bf and LogBf are encoded as null when they aren't finite.
+type Result struct {
+	Bf                float64      `json:"bf"`
+	LogBf             float64      `json:"logbf"`
+	LikelihoodPlot    []Point      `json:"likelihoodPlotData"`
+	AltPriorPlot      []Point      `json:"altpriorPlotData"`
+	NullPriorPlot     []Point      `json:"nullpriorPlotData"`
+	AltPriorLims      Limits       `json:"altpriorLims"`
+	Xmin              float64      `json:"xmin"`
+	Xmax              float64      `json:"xmax"`
+	Names             Names        `json:"names"`
+	Observation       float64      `json:"observation"`
+	AltPoint          float64      `json:"altpoint"`
+	NullPoint         float64      `json:"nullpoint"`
+	Comparison        []Prediction `json:"comparison"`
+	Ratio             []Point      `json:"ratio"`
+	AltPosteriorPlot  []Point      `json:"altposteriorPlotData"`
+	NullPosteriorPlot []Point      `json:"nullposteriorPlotData"`
+}
+
+// MarshalJSON encodes the result, with null in place of a Bf or LogBf that
+// isn't finite
+func (r Result) MarshalJSON() ([]byte, error) {
+	type plain Result
+	return json.Marshal(struct {
+		plain
+		Bf    *float64 `json:"bf"`
+		LogBf *float64 `json:"logbf"`
+	}{plain(r), bayesfactor.Finite(r.Bf), bayesfactor.Finite(r.LogBf)})
+}
+
+// MinMax finds the minimum and the maxium of array
+func MinMax(array []float64) (float64, float64) {
+	var max float64 = array[0]
+	var min float64 = array[0]
+	for _, value := range array {
+		if max < value {
+			max = value
+		}
+		if min > value {
+			min = value
+		}
+	}
+	return min, max
+}
+
+func seq(min float64, max float64) [101]float64 {
+	step := (max - min) / (100)
+	var values [101]float64
+	t := min
+	for i := 0; i < 101; i++ {
+		values[i] = t
+		t += step
+	}
+	values[100] = max
+
+	return values
+}
+
+// Predictions computes the marginal likelihood of a range of possible
+// observations under the alternative and null models (comparison) and
+// the log10 Bayes factor for each observation (ratio). Observations for
+// which either marginal likelihood can't be computed are skipped.
+func Predictions(
+	likelihood bayesfactor.LikelihoodDefinition,
+	altprior bayesfactor.PriorDefinition,
+	nullprior bayesfactor.PriorDefinition,
+	minvalue float64,
+	maxvalue float64,
+	currentObservation float64,
+) ([]Prediction, []Point) {
+
+	comparison := []Prediction{}
+	ratio := []Point{}
+
+	// copy the parameters so that the caller's definition is left untouched
+	var newLikelihood bayesfactor.LikelihoodDefinition
+	newLikelihood.Name = likelihood.Name
+	newLikelihood.Params = append([]float64(nil), likelihood.Params...)
+
+	// the grid of observations includes the current observation
+	newLikelihood.Params[0] = currentObservation
+	observations := bayesfactor.Observations(newLikelihood, minvalue, maxvalue)
+	for _, ob := range observations {
+		newLikelihood.Params[0] = ob
+		altModel, altErr := bayesfactor.Pp(newLikelihood, altprior)
+		nullModel, nullErr := bayesfactor.Pp(newLikelihood, nullprior)
+		if altErr != nil || nullErr != nil {
+			continue
+		}
+		thisBf := (altModel.LogAuc - nullModel.LogAuc) / math.Ln10
+		if math.IsNaN(thisBf) {
+			continue
+		}
+		ratio = append(ratio, Point{X: ob, Y: thisBf})
+		comparison = append(comparison, Prediction{X: ob, Y: altModel.Auc, Type: AltModel})
+		comparison = append(comparison, Prediction{X: ob, Y: nullModel.Auc, Type: NullModel})
+	}
+
+	return comparison, ratio
+}
+
+// posteriorCurve evaluates the posterior density at 101 equally spaced
+// points between min and max
+func posteriorCurve(pred bayesfactor.Predictive, min float64, max float64) []Point {
+	result := []Point{}
+	for _, x := range seq(min, max) {
+		result = append(result, Point{X: x, Y: math.Exp(pred.LogFunction(x) - pred.LogAuc)})
+	}
+	return result
+}
+
+// CredibleLevel is the level of the credible intervals reported by Posterior
+const CredibleLevel = 0.95
+
+// PosteriorResult is the posterior distribution obtained from a single prior
+type PosteriorResult struct {
+	Marginal float64                      `json:"marginal"`
+	Plot     []Point                      `json:"posteriorPlotData"`
+	Summary  bayesfactor.PosteriorSummary `json:"summary"`
+}
+
+// Posterior computes the marginal likelihood, the posterior curve and the
+// posterior summaries for a single prior. The curve covers the same range
+// as the prior plot. For point priors the posterior is the same single
+// point as the prior.
+func Posterior(likelihood bayesfactor.LikelihoodDefinition, prior bayesfactor.PriorDefinition) (PosteriorResult, error) {
+
+	var result PosteriorResult
+
+	// plot the prior that Pp uses
+	prior = bayesfactor.SupportPrior(prior, likelihood)
+	pred, err := bayesfactor.Pp(likelihood, prior)
+	if err != nil {
+		return result, err
+	}
+	priorPlotData, err := PriorPlot(prior)
+	if err != nil {
+		return result, err
+	}
+
+	posterior, err := bayesfactor.NewPosterior(pred)
+	if err != nil {
+		return result, err
+	}
+
+	result.Marginal = pred.Auc
+	result.Summary = posterior.Summary(CredibleLevel)
+	if prior.Name == "point" {
+		result.Plot = priorPlotData
+		return result, nil
+	}
+	result.Plot = posteriorCurve(pred, priorPlotData[0].X, priorPlotData[len(priorPlotData)-1].X)
+	return result, nil
+}
+
+// Compute computes the Bayes factor together with the plot data for the
+// likelihood, priors, posteriors and model predictions
+func Compute(
+	likelihood bayesfactor.LikelihoodDefinition,
+	altprior bayesfactor.PriorDefinition,
+	nullprior bayesfactor.PriorDefinition,
+) (Result, error) {
+
+	var result Result
+
+	// plot the priors that Pp uses
+	altprior = bayesfactor.SupportPrior(altprior, likelihood)
+	nullprior = bayesfactor.SupportPrior(nullprior, likelihood)
+	// the predictives give both the Bayes factor and the posteriors
+	models, err := bayesfactor.Compare(likelihood, altprior, nullprior)
+	if err != nil {
+		return result, err
+	}
+	altPriorProd, nullPriorProd := models.Alt, models.Null
+
+	likelihoodPlotData, err := LikelihoodPlot(likelihood)
+	if err != nil {
+		return result, err
+	}
+	altpriorPlotData, err := PriorPlot(altprior)
+	if err != nil {
+		return result, err
+	}
+	nullpriorPlotData, err := PriorPlot(nullprior)
+	if err != nil {
+		return result, err
+	}
+
+	observation := likelihood.Params[0]
+
+	// find the limits of the likelihood function and priors
+	likelihoodLimitsXmin := likelihoodPlotData[0].X
+	likelihoodLimitsXmax := likelihoodPlotData[len(likelihoodPlotData)-1].X
+
+	altpriorLimitsXmin := altpriorPlotData[0].X
+	altpriorLimitsXmax := altpriorPlotData[len(altpriorPlotData)-1].X
+
+	var nullpriorLimitsXmin float64
+	var nullpriorLimitsXmax float64
+	if nullprior.Name == "point" {
+		nullpriorLimitsXmin = altpriorLimitsXmin
+		nullpriorLimitsXmax = altpriorLimitsXmax
+	} else {
+		nullpriorLimitsXmin = nullpriorPlotData[0].X
+		nullpriorLimitsXmax = nullpriorPlotData[len(nullpriorPlotData)-1].X
+	}
+
+	xmin, _ := MinMax([]float64{likelihoodLimitsXmin, altpriorLimitsXmin, nullpriorLimitsXmin})
+	_, xmax := MinMax([]float64{likelihoodLimitsXmax, altpriorLimitsXmax, nullpriorLimitsXmax})
+
+	_, lim := MinMax([]float64{math.Abs(xmin), math.Abs(xmax)})
+
+	if xmin < 0 {
+		xmin = lim * -1
+	} else {
+		xmin = lim * 1
+	}
+
+	if xmax < 0 {
+		xmax = lim * -1
+	} else {
+		xmax = lim * 1
+	}
+
+	altPoint := altPriorProd.Auc
+	nullPoint := nullPriorProd.Auc
+
+	altPosteriorPlot := posteriorCurve(altPriorProd, altpriorLimitsXmin, altpriorLimitsXmax)
+	nullPosteriorPlot := posteriorCurve(nullPriorProd, nullpriorLimitsXmin, nullpriorLimitsXmax)
+
+	comparison, ratio := Predictions(
+		likelihood,
+		altprior,
+		nullprior,
+		xmin,
+		xmax,
+		observation)
+
+	result = Result{
+		Bf:                models.BF10(),
+		LogBf:             models.LogBF10,
+		LikelihoodPlot:    likelihoodPlotData,
+		AltPriorPlot:      altpriorPlotData,
+		NullPriorPlot:     nullpriorPlotData,
+		AltPriorLims:      Limits{Xmin: altpriorLimitsXmin, Xmax: altpriorLimitsXmax},
+		Xmin:              altpriorLimitsXmin,
+		Xmax:              altpriorLimitsXmax,
+		Names:             Names{Likelihood: likelihood.Name, Alt: altprior.Name, Null: nullprior.Name},
+		Observation:       observation,
+		AltPoint:          altPoint,
+		NullPoint:         nullPoint,
+		Comparison:        comparison,
+		Ratio:             ratio,
+		AltPosteriorPlot:  altPosteriorPlot,
+		NullPosteriorPlot: nullPosteriorPlot,
+	}
+
+	return result, nil
+}
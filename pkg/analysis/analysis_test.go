@@ -0,0 +1,362 @@
+package analysis
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"pkg/bayesfactor"
+)
+
+func compare(t *testing.T, got, want float64) {
+
+	const tolerance = .001
+	opt := cmp.Comparer(func(x, y float64) bool {
+		diff := math.Abs(x - y)
+		mean := math.Abs(x+y) / 2.0
+		return (diff / mean) < tolerance
+	})
+
+	if !cmp.Equal(got, want, opt) {
+		t.Fatalf("got %v, wanted %v", got, want)
+	}
+}
+
+func TestCompute(t *testing.T) {
+
+	likelihood := bayesfactor.LikelihoodDefinition{Name: "normal", Params: []float64{5.5, 32.35}}
+	altprior := bayesfactor.PriorDefinition{Name: "normal", Params: []float64{0, 13.3, 0, math.Inf(1)}}
+	nullprior := bayesfactor.PriorDefinition{Name: "point", Params: []float64{0}}
+
+	result, err := Compute(likelihood, altprior, nullprior)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+
+	compare(t, result.Bf, 0.9745934)
+	compare(t, result.LogBf, math.Log(0.9745934))
+	compare(t, result.AltPoint/result.NullPoint, result.Bf)
+
+	if len(result.LikelihoodPlot) != 101 || len(result.AltPriorPlot) != 101 {
+		t.Fatalf("got %v and %v points, wanted 101", len(result.LikelihoodPlot), len(result.AltPriorPlot))
+	}
+	if len(result.NullPriorPlot) != 1 || result.NullPriorPlot[0] != (Point{X: 0, Y: 1}) {
+		t.Fatalf("got null prior plot %v, wanted a single point", result.NullPriorPlot)
+	}
+	compare(t, result.LikelihoodPlot[0].X, 5.5-4*32.35)
+	// the half-normal prior is plotted over its support, up to the median
+	// plus four robust sds: 13.3 * (qnorm(0.75) + 4 * (qnorm(0.875) -
+	// qnorm(0.625)) / (2 * qnorm(0.75)))
+	if result.AltPriorLims.Xmin != 0 {
+		t.Fatalf("got xmin %v, wanted 0", result.AltPriorLims.Xmin)
+	}
+	compare(t, result.AltPriorLims.Xmax, 41.77104)
+
+	if result.Observation != 5.5 || likelihood.Params[0] != 5.5 {
+		t.Fatalf("observation changed to %v", likelihood.Params[0])
+	}
+
+	if len(result.Comparison) != 2*len(result.Ratio) {
+		t.Fatalf("got %v comparison points for %v ratio points", len(result.Comparison), len(result.Ratio))
+	}
+
+	// the ratio at the current observation should match the bf
+	for _, point := range result.Ratio {
+		if point.X == result.Observation {
+			compare(t, math.Pow(10, point.Y), result.Bf)
+		}
+	}
+
+	want := Names{Likelihood: "normal", Alt: "normal", Null: "point"}
+	if result.Names != want {
+		t.Fatalf("got %v, wanted %v", result.Names, want)
+	}
+}
+
+func TestComputeBinomial(t *testing.T) {
+
+	likelihood := bayesfactor.LikelihoodDefinition{Name: "binomial", Params: []float64{8, 11}}
+	altprior := bayesfactor.PriorDefinition{Name: "beta", Params: []float64{2.5, 1}}
+	nullprior := bayesfactor.PriorDefinition{Name: "point", Params: []float64{0.5}}
+
+	result, err := Compute(likelihood, altprior, nullprior)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+
+	compare(t, result.Bf, 1/0.6632996)
+
+	// one prediction for each possible number of successes
+	if len(result.Ratio) != 12 {
+		t.Fatalf("got %v ratio points, wanted 12", len(result.Ratio))
+	}
+
+	// the predictions of each model should sum to one
+	var altSum, nullSum float64
+	for _, p := range result.Comparison {
+		switch p.Type {
+		case AltModel:
+			altSum += p.Y
+		case NullModel:
+			nullSum += p.Y
+		}
+	}
+	compare(t, altSum, 1)
+	compare(t, nullSum, 1)
+
+	// a prior with infinite bounds is restricted to [0, 1]
+	altprior = bayesfactor.PriorDefinition{Name: "normal", Params: []float64{0.5, 1, math.Inf(-1), math.Inf(1)}}
+	result, err = Compute(likelihood, altprior, nullprior)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	if result.AltPriorLims.Xmin != 0 || result.AltPriorLims.Xmax != 1 {
+		t.Fatalf("got prior limits %+v, wanted [0, 1]", result.AltPriorLims)
+	}
+	altSum = 0
+	for _, p := range result.Comparison {
+		if p.Type == AltModel {
+			altSum += p.Y
+		}
+	}
+	compare(t, altSum, 1)
+}
+
+func TestComputePoisson(t *testing.T) {
+
+	likelihood := bayesfactor.LikelihoodDefinition{Name: "poisson", Params: []float64{3, 2}}
+	altprior := bayesfactor.PriorDefinition{Name: "gamma", Params: []float64{2, 1}}
+	nullprior := bayesfactor.PriorDefinition{Name: "point", Params: []float64{1}}
+
+	result, err := Compute(likelihood, altprior, nullprior)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	compare(t, result.Bf, 0.7297833)
+	if result.AltPriorLims.Xmin != 0 {
+		t.Fatalf("got prior limits %v, wanted them to start at 0", result.AltPriorLims)
+	}
+
+	// the predictions are for whole counts, including the observed count
+	observed := false
+	for _, p := range result.Ratio {
+		if p.X != math.Floor(p.X) || p.X < 0 {
+			t.Fatalf("got prediction for count %v", p.X)
+		}
+		observed = observed || p.X == 3
+	}
+	if !observed || len(result.Ratio) > 102 {
+		t.Fatalf("got %v ratio points %v", len(result.Ratio), result.Ratio)
+	}
+}
+
+func TestComputeBinomial2(t *testing.T) {
+
+	likelihood := bayesfactor.LikelihoodDefinition{Name: "binomial2_diff", Params: []float64{10, 50, 18, 50}}
+	altprior := bayesfactor.PriorDefinition{Name: "uniform", Params: []float64{-1, 1}}
+	nullprior := bayesfactor.PriorDefinition{Name: "point", Params: []float64{0}}
+
+	result, err := Compute(likelihood, altprior, nullprior)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	compare(t, result.Bf, 0.6266555)
+
+	// one prediction for each possible number of successes in the first
+	// group, with the second group as observed, so they sum to the
+	// probability of the second group under each model
+	if len(result.Ratio) != 51 {
+		t.Fatalf("got %v ratio points, wanted 51", len(result.Ratio))
+	}
+	var altSum, nullSum float64
+	for _, p := range result.Comparison {
+		switch p.Type {
+		case AltModel:
+			altSum += p.Y
+		case NullModel:
+			nullSum += p.Y
+		}
+	}
+	if !(altSum > 0 && altSum < 1 && nullSum > 0 && nullSum < 1) {
+		t.Fatalf("got predictions summing to %v and %v", altSum, nullSum)
+	}
+}
+
+func TestComputeCorrelation(t *testing.T) {
+
+	likelihood := bayesfactor.LikelihoodDefinition{Name: "correlation", Params: []float64{0.3, 50}}
+	altprior := bayesfactor.PriorDefinition{Name: "stretched_beta", Params: []float64{1, 1, 0, 1}}
+	nullprior := bayesfactor.PriorDefinition{Name: "point", Params: []float64{0}}
+
+	result, err := Compute(likelihood, altprior, nullprior)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	compare(t, result.Bf, 3.0518846)
+
+	// correlations of -1 and 1 can't be observed
+	if len(result.Ratio) < 99 {
+		t.Fatalf("got %v ratio points", len(result.Ratio))
+	}
+	for _, p := range result.Ratio {
+		if !(p.X > -1 && p.X < 1) {
+			t.Fatalf("got prediction for r = %v", p.X)
+		}
+	}
+	for _, p := range result.LikelihoodPlot {
+		if !(p.X >= -1 && p.X <= 1) {
+			t.Fatalf("got likelihood at rho = %v", p.X)
+		}
+	}
+}
+
+func TestPredictionsNoncentral(t *testing.T) {
+
+	// large effects with a large sample are no longer dropped
+	likelihood := bayesfactor.LikelihoodDefinition{Name: "noncentral_d", Params: []float64{0.5, 1000}}
+	altprior := bayesfactor.PriorDefinition{Name: "cauchy", Params: []float64{0, 0.707, math.Inf(-1), math.Inf(1)}}
+	nullprior := bayesfactor.PriorDefinition{Name: "point", Params: []float64{0}}
+
+	comparison, ratio := Predictions(likelihood, altprior, nullprior, -2, 2, 0.5)
+	if len(ratio) != len(bayesfactor.Observations(likelihood, -2, 2)) || len(comparison) != 2*len(ratio) {
+		t.Fatalf("got %v ratio and %v comparison points", len(ratio), len(comparison))
+	}
+	for _, point := range ratio {
+		if math.IsInf(point.Y, 0) || math.IsNaN(point.Y) {
+			t.Fatalf("got log10 BF %v at %v", point.Y, point.X)
+		}
+	}
+}
+
+// exponential is a prior family with a rate, registered for
+// TestComputeRegistered
+type exponential struct{}
+
+func (exponential) Params() []string { return []string{"rate"} }
+
+func (exponential) Validate(params []float64) error {
+	if !(params[0] > 0) {
+		return &bayesfactor.ModelError{Param: "rate", Err: bayesfactor.ErrNonPositiveSD}
+	}
+	return nil
+}
+
+func (exponential) LogFunction(params []float64) func(x float64) float64 {
+	return func(x float64) float64 {
+		if x < 0 {
+			return math.Inf(-1)
+		}
+		return math.Log(params[0]) - params[0]*x
+	}
+}
+
+func (exponential) Support(params []float64) (float64, float64) {
+	return 0, math.Inf(1)
+}
+
+func (exponential) TypicalRange(params []float64) (float64, float64) {
+	return 0, 5 / params[0]
+}
+
+func init() {
+	bayesfactor.RegisterPrior("test_exponential", exponential{})
+}
+
+func TestComputeRegistered(t *testing.T) {
+
+	// with a normal likelihood with a mean and sd of 1, the marginal
+	// likelihood of an exponential(1) prior is exp(-1/2) / 2
+	likelihood := bayesfactor.LikelihoodDefinition{Name: "normal", Params: []float64{1, 1}}
+	altprior := bayesfactor.PriorDefinition{Name: "test_exponential", Params: []float64{1}}
+	nullprior := bayesfactor.PriorDefinition{Name: "point", Params: []float64{0}}
+
+	result, err := Compute(likelihood, altprior, nullprior)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	compare(t, result.AltPoint, math.Exp(-0.5)/2)
+	if result.AltPriorLims != (Limits{Xmin: 0, Xmax: 5}) {
+		t.Fatalf("got limits %v, wanted [0, 5]", result.AltPriorLims)
+	}
+	if len(result.Ratio) == 0 {
+		t.Fatalf("got no model predictions")
+	}
+}
+
+func TestComputeInvalid(t *testing.T) {
+
+	likelihood := bayesfactor.LikelihoodDefinition{Name: "normal", Params: []float64{5.5, -1}}
+	altprior := bayesfactor.PriorDefinition{Name: "cauchy", Params: []float64{0, 1, math.Inf(-1), math.Inf(1)}}
+	nullprior := bayesfactor.PriorDefinition{Name: "point", Params: []float64{0}}
+
+	if _, err := Compute(likelihood, altprior, nullprior); !errors.Is(err, bayesfactor.ErrNonPositiveSD) {
+		t.Fatalf("got error %v, wanted %v", err, bayesfactor.ErrNonPositiveSD)
+	}
+}
+
+func TestComputeLargeBf(t *testing.T) {
+
+	// the Bayes factor overflows a float64
+	likelihood := bayesfactor.LikelihoodDefinition{Name: "noncentral_d", Params: []float64{1.5, 2000}}
+	altprior := bayesfactor.PriorDefinition{Name: "cauchy", Params: []float64{0, 0.707, math.Inf(-1), math.Inf(1)}}
+	nullprior := bayesfactor.PriorDefinition{Name: "point", Params: []float64{0}}
+
+	result, err := Compute(likelihood, altprior, nullprior)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	if !math.IsInf(result.Bf, 1) {
+		t.Fatalf("got bf %v, wanted +Inf", result.Bf)
+	}
+	compare(t, result.LogBf, 1173.6)
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	if bf, ok := decoded["bf"]; !ok || bf != nil {
+		t.Fatalf("got bf %v, wanted null", bf)
+	}
+	if logbf, _ := decoded["logbf"].(float64); logbf != result.LogBf {
+		t.Fatalf("got logbf %v, wanted %v", decoded["logbf"], result.LogBf)
+	}
+}
+
+func TestPosterior(t *testing.T) {
+
+	likelihood := bayesfactor.LikelihoodDefinition{Name: "normal", Params: []float64{5, 10}}
+	prior := bayesfactor.PriorDefinition{Name: "normal", Params: []float64{0, 10, math.Inf(-1), math.Inf(1)}}
+
+	result, err := Posterior(likelihood, prior)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+
+	// normal-normal: the marginal is normal with sd sqrt(10^2 + 10^2)
+	compare(t, result.Marginal, 0.02650035)
+
+	// the posterior is normal with mean 2.5 and sd sqrt(50)
+	if len(result.Plot) != 101 {
+		t.Fatalf("got %v points, wanted 101", len(result.Plot))
+	}
+	compare(t, result.Plot[50].Y, 1/math.Sqrt(2*math.Pi*50)*math.Exp(-2.5*2.5/100))
+	compare(t, result.Summary.Mean, 2.5)
+	compare(t, result.Summary.SD, math.Sqrt(50))
+	compare(t, result.Summary.HDI[1], 2.5+1.959964*math.Sqrt(50))
+
+	point := bayesfactor.PriorDefinition{Name: "point", Params: []float64{0}}
+	result, err = Posterior(likelihood, point)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	if len(result.Plot) != 1 || result.Plot[0].X != 0 {
+		t.Fatalf("got %v, wanted a single point at 0", result.Plot)
+	}
+}
@@ -0,0 +1,284 @@
+package analysis
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"runtime"
+	"sort"
+	"sync"
+
+	"golang.org/x/exp/rand"
+
+	"pkg/bayesfactor"
+	"pkg/distributions"
+)
+
+// DefaultSimulations is the number of simulated studies used by Simulate
+// when Design.Simulations is not set
+const DefaultSimulations = 1000
+
+// DefaultThreshold is the Bayes factor counted as compelling evidence when
+// Design.Threshold is not set
+const DefaultThreshold = 10
+
+// ErrDesign is returned when a design can't be simulated
+var ErrDesign = errors.New("invalid design")
+
+// Design describes a planned study for a Bayes factor design analysis. The
+// likelihood gives the family and the planned sample size; its observation
+// (the first parameter) is replaced by the simulated data. The true effect
+// is either fixed at Effect or, if DesignPrior is set, drawn from the design
+// prior for each simulated study.
+//
+// The supported likelihoods are noncentral_d (effect: d, sample size: n),
+// noncentral_d2 (d, n1 and n2), normal (mean, with the standard error as
+// the sd), binomial (probability of success, trials), poisson (rate,
+// exposure) and negative_binomial (rate, size and exposure).
+type Design struct {
+	Likelihood  bayesfactor.LikelihoodDefinition `json:"likelihoodDef"`
+	AltPrior    bayesfactor.PriorDefinition      `json:"altpriorDef"`
+	NullPrior   bayesfactor.PriorDefinition      `json:"nullpriorDef"`
+	Effect      float64                          `json:"effect"`
+	DesignPrior *bayesfactor.PriorDefinition     `json:"designpriorDef,omitempty"`
+	Simulations int                              `json:"simulations"`
+	Threshold   float64                          `json:"threshold"`
+	Seed        uint64                           `json:"seed"`
+
+	// UnderNull is true when the data are generated under the null
+	// hypothesis, in which case evidence for the null is compelling and
+	// evidence for the alternative is misleading
+	UnderNull bool `json:"underNull"`
+}
+
+// DesignResult is the distribution of Bayes factors from a design analysis.
+// Simulated studies where the Bayes factor is undefined are reported as NaN
+// and only count towards Inconclusive. Bayes factors and quantiles that
+// aren't finite are encoded as null in JSON.
+type DesignResult struct {
+	BF10         []float64 `json:"bf10"`
+	Effects      []float64 `json:"effects"`
+	Observations []float64 `json:"observations"`
+	Threshold    float64   `json:"threshold"`
+
+	// quantiles (5%, 25%, 50%, 75%, 95%) of BF10
+	Quantiles [5]float64 `json:"quantiles"`
+
+	// probabilities of BF10 >= Threshold, BF01 >= Threshold and neither
+	AltEvidence  float64 `json:"altEvidence"`
+	NullEvidence float64 `json:"nullEvidence"`
+	Inconclusive float64 `json:"inconclusive"`
+
+	// probabilities of evidence for the true and for the false hypothesis
+	Compelling float64 `json:"compelling"`
+	Misleading float64 `json:"misleading"`
+}
+
+// MarshalJSON encodes the result, with null in place of Bayes factors and
+// quantiles that aren't finite
+func (r DesignResult) MarshalJSON() ([]byte, error) {
+	type plain DesignResult
+	var quantiles [5]*float64
+	for i, q := range r.Quantiles {
+		quantiles[i] = bayesfactor.Finite(q)
+	}
+	bfs := make([]*float64, len(r.BF10))
+	for i, bf := range r.BF10 {
+		bfs[i] = bayesfactor.Finite(bf)
+	}
+	return json.Marshal(struct {
+		plain
+		BF10      []*float64  `json:"bf10"`
+		Quantiles [5]*float64 `json:"quantiles"`
+	}{plain(r), bfs, quantiles})
+}
+
+// Simulate runs a Bayes factor design analysis. Data are simulated from
+// the design, the Bayes factor is computed for each simulated study and
+// the distribution of the Bayes factors is summarised. The simulated data
+// are reproducible for a given Seed.
+func Simulate(design Design) (DesignResult, error) {
+
+	var result DesignResult
+
+	if design.Simulations == 0 {
+		design.Simulations = DefaultSimulations
+	}
+	if design.Threshold == 0 {
+		design.Threshold = DefaultThreshold
+	}
+	if design.Simulations < 0 || !(design.Threshold >= 1) || math.IsInf(design.Threshold, 0) {
+		return result, ErrDesign
+	}
+
+	// check the model once before simulating
+	if _, err := bayesfactor.CreateLikelihood(design.Likelihood); err != nil {
+		return result, err
+	}
+	if _, err := bayesfactor.CreatePrior(design.AltPrior); err != nil {
+		return result, err
+	}
+	if _, err := bayesfactor.CreatePrior(design.NullPrior); err != nil {
+		return result, err
+	}
+	var designPrior bayesfactor.Prior
+	if design.DesignPrior != nil {
+		var err error
+		if designPrior, err = bayesfactor.CreatePrior(*design.DesignPrior); err != nil {
+			return result, err
+		}
+	}
+
+	// the data are drawn sequentially so that they only depend on the seed
+	src := rand.NewSource(design.Seed)
+	result.Effects = make([]float64, design.Simulations)
+	result.Observations = make([]float64, design.Simulations)
+	for i := range result.Observations {
+		effect := design.Effect
+		if design.DesignPrior != nil {
+			effect = designPrior.Rand(src)
+		}
+		obs, err := simulateObservation(design.Likelihood, effect, src)
+		if err != nil {
+			return result, err
+		}
+		result.Effects[i] = effect
+		result.Observations[i] = obs
+	}
+
+	bfs, err := bayesfactors(design, result.Observations)
+	if err != nil {
+		return result, err
+	}
+
+	result.BF10 = bfs
+	result.Threshold = design.Threshold
+	summarise(&result, design.UnderNull)
+	return result, nil
+}
+
+// bayesfactors computes BF10 for each simulated observation. The Bayes
+// factors are computed in parallel.
+func bayesfactors(design Design, observations []float64) ([]float64, error) {
+
+	bfs := make([]float64, len(observations))
+	errs := make([]error, len(observations))
+
+	workers := runtime.NumCPU()
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				likelihood := design.Likelihood
+				likelihood.Params = append([]float64(nil), design.Likelihood.Params...)
+				likelihood.Params[0] = observations[i]
+				bfs[i], errs[i] = bayesfactor.Bayesfactor(likelihood, design.AltPrior, design.NullPrior)
+			}
+		}()
+	}
+	for i := range observations {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	for i, err := range errs {
+		if errors.Is(err, bayesfactor.ErrUndefinedRatio) {
+			bfs[i] = math.NaN()
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return bfs, nil
+}
+
+func summarise(result *DesignResult, underNull bool) {
+
+	n := float64(len(result.BF10))
+	if n == 0 {
+		return
+	}
+
+	var alt, null float64
+	defined := make([]float64, 0, len(result.BF10))
+	for _, bf := range result.BF10 {
+		if math.IsNaN(bf) {
+			continue
+		}
+		defined = append(defined, bf)
+		if bf >= result.Threshold {
+			alt++
+		} else if 1/bf >= result.Threshold {
+			null++
+		}
+	}
+
+	result.AltEvidence = alt / n
+	result.NullEvidence = null / n
+	result.Inconclusive = 1 - result.AltEvidence - result.NullEvidence
+	if underNull {
+		result.Compelling, result.Misleading = result.NullEvidence, result.AltEvidence
+	} else {
+		result.Compelling, result.Misleading = result.AltEvidence, result.NullEvidence
+	}
+
+	sort.Float64s(defined)
+	for i, p := range []float64{0.05, 0.25, 0.5, 0.75, 0.95} {
+		result.Quantiles[i] = quantile(defined, p)
+	}
+}
+
+// quantile of sorted values using linear interpolation
+func quantile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return math.NaN()
+	}
+	h := p * float64(len(sorted)-1)
+	i := int(math.Floor(h))
+	if i+1 >= len(sorted) {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[i] + (h-float64(i))*(sorted[i+1]-sorted[i])
+}
+
+// simulateObservation draws the observed statistic of a study with the
+// given likelihood and true effect
+func simulateObservation(likelihood bayesfactor.LikelihoodDefinition, effect float64, src rand.Source) (float64, error) {
+
+	var obs float64
+	switch likelihood.Name {
+	case "noncentral_d":
+		n := likelihood.Params[1]
+		obs = distributions.Rt(n-1, effect*math.Sqrt(n), src) / math.Sqrt(n)
+	case "noncentral_d2":
+		n1, n2 := likelihood.Params[1], likelihood.Params[2]
+		scale := math.Sqrt(n1 * n2 / (n1 + n2))
+		obs = distributions.Rt(n1+n2-2, effect*scale, src) / scale
+	case "normal":
+		sd := likelihood.Params[1]
+		obs = distributions.Rnorm(effect, sd, src)
+	case "binomial":
+		trials := likelihood.Params[1]
+		obs = distributions.Rbinom(trials, effect, src)
+	case "poisson":
+		exposure := likelihood.Params[1]
+		obs = distributions.Rpois(effect*exposure, src)
+	case "negative_binomial":
+		size, exposure := likelihood.Params[1], likelihood.Params[2]
+		obs = distributions.Rnbinom(size, effect*exposure, src)
+	default:
+		return math.NaN(), ErrDesign
+	}
+
+	// invalid parameters, such as a binomial effect that isn't a
+	// probability, give NaN
+	if math.IsNaN(obs) {
+		return obs, ErrDesign
+	}
+	return obs, nil
+}
@@ -0,0 +1,137 @@
+package analysis
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+
+	"pkg/bayesfactor"
+)
+
+func TestSimulate(t *testing.T) {
+
+	inf := math.Inf(1)
+
+	// with a normal likelihood (sd 1), a normal(0, 3) alternative prior and
+	// data generated under the null, BF10 >= 3 when |x| >= 2.236 and
+	// BF01 >= 3 when |x| <= 0.342
+	design := Design{
+		Likelihood:  bayesfactor.LikelihoodDefinition{Name: "normal", Params: []float64{0, 1}},
+		AltPrior:    bayesfactor.PriorDefinition{Name: "normal", Params: []float64{0, 3, -inf, inf}},
+		NullPrior:   bayesfactor.PriorDefinition{Name: "point", Params: []float64{0}},
+		Simulations: 200,
+		Threshold:   3,
+		Seed:        1,
+		UnderNull:   true,
+	}
+	result, err := Simulate(design)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	if len(result.BF10) != 200 || len(result.Effects) != 200 {
+		t.Fatalf("got %v bayes factors, wanted 200", len(result.BF10))
+	}
+	if math.Abs(result.Misleading-0.0253) > 0.05 || math.Abs(result.Compelling-0.268) > 0.1 {
+		t.Fatalf("got compelling %v and misleading %v", result.Compelling, result.Misleading)
+	}
+	compare(t, result.AltEvidence+result.NullEvidence+result.Inconclusive, 1)
+
+	// the same seed gives the same data
+	again, err := Simulate(design)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	for i := range result.Observations {
+		if result.Observations[i] != again.Observations[i] {
+			t.Fatalf("simulation %v gave %v and %v", i, result.Observations[i], again.Observations[i])
+		}
+		compare(t, again.BF10[i], result.BF10[i])
+	}
+
+	// a large effect drawn from a design prior should almost always give
+	// compelling evidence for the alternative
+	designPrior := bayesfactor.PriorDefinition{Name: "normal", Params: []float64{1, 0.1, 0, inf}}
+	design = Design{
+		Likelihood:  bayesfactor.LikelihoodDefinition{Name: "noncentral_d", Params: []float64{0, 50}},
+		AltPrior:    bayesfactor.PriorDefinition{Name: "cauchy", Params: []float64{0, 0.707, -inf, inf}},
+		NullPrior:   bayesfactor.PriorDefinition{Name: "point", Params: []float64{0}},
+		DesignPrior: &designPrior,
+		Simulations: 20,
+		Seed:        2,
+	}
+	result, err = Simulate(design)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	if result.Compelling < 0.9 || result.Quantiles[0] < DefaultThreshold {
+		t.Fatalf("got compelling %v and quantiles %v", result.Compelling, result.Quantiles)
+	}
+	for _, effect := range result.Effects {
+		if effect < 0 {
+			t.Fatalf("got effect %v outside the design prior", effect)
+		}
+	}
+
+	// rates are simulated as counts
+	design = Design{
+		Likelihood:  bayesfactor.LikelihoodDefinition{Name: "negative_binomial", Params: []float64{0, 50, 10}},
+		AltPrior:    bayesfactor.PriorDefinition{Name: "gamma", Params: []float64{2, 1}},
+		NullPrior:   bayesfactor.PriorDefinition{Name: "point", Params: []float64{1}},
+		Effect:      3,
+		Simulations: 50,
+		Threshold:   3,
+		Seed:        3,
+	}
+	result, err = Simulate(design)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	if result.Compelling < 0.8 {
+		t.Fatalf("got compelling %v", result.Compelling)
+	}
+
+	// binomial designs need an effect that is a probability
+	design = Design{
+		Likelihood: bayesfactor.LikelihoodDefinition{Name: "binomial", Params: []float64{0, 20}},
+		AltPrior:   bayesfactor.PriorDefinition{Name: "beta", Params: []float64{1, 1}},
+		NullPrior:  bayesfactor.PriorDefinition{Name: "point", Params: []float64{0.5}},
+		Effect:     1.5,
+	}
+	if _, err := Simulate(design); !errors.Is(err, ErrDesign) {
+		t.Fatalf("got error %v, wanted %v", err, ErrDesign)
+	}
+}
+
+func TestDesignResultJSON(t *testing.T) {
+
+	// undefined Bayes factors, and the quantiles when every Bayes factor is
+	// undefined, are NaN
+	result := DesignResult{BF10: []float64{math.NaN(), math.Inf(1), 2}, Threshold: 3}
+	for i := range result.Quantiles {
+		result.Quantiles[i] = math.NaN()
+	}
+	result.Quantiles[4] = 2
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	var decoded struct {
+		BF10      []*float64 `json:"bf10"`
+		Quantiles []*float64 `json:"quantiles"`
+		Threshold float64    `json:"threshold"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	if len(decoded.BF10) != 3 || decoded.BF10[0] != nil || decoded.BF10[1] != nil || decoded.BF10[2] == nil || *decoded.BF10[2] != 2 {
+		t.Fatalf("got bf10 %s, wanted [null, null, 2]", data)
+	}
+	if len(decoded.Quantiles) != 5 || decoded.Quantiles[0] != nil || decoded.Quantiles[4] == nil || *decoded.Quantiles[4] != 2 {
+		t.Fatalf("got quantiles %s, wanted null in place of NaN", data)
+	}
+	if decoded.Threshold != 3 {
+		t.Fatalf("got threshold %v, wanted 3", decoded.Threshold)
+	}
+}
@@ -0,0 +1,15 @@
+module analysis
+
+go 1.16
+
+require (
+	github.com/google/go-cmp v0.5.6
+	golang.org/x/exp v0.0.0-20191002040644-a1355ae1e2c3
+	gonum.org/v1/gonum v0.9.3
+	pkg/bayesfactor v1.0.0
+	pkg/distributions v1.0.0
+)
+
+replace pkg/bayesfactor => ../bayesfactor
+
+replace pkg/distributions => ../distributions
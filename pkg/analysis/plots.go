@@ -0,0 +1,191 @@
+package analysis
+
+import (
+	"math"
+
+	"pkg/bayesfactor"
+)
+
+// Point is a single point on a plotted curve
+type Point struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// curve evaluates fun at 101 equally spaced points between min and max,
+// replacing undefined values at the end points with the value at the
+// neighbouring point
+func curve(fun func(x float64) float64, min float64, max float64) []Point {
+
+	result := make([]Point, 0, 101)
+
+	step := (max - min) / 100
+	x := min
+	for i := 0; i < 101; i++ {
+		y := fun(x)
+		if math.IsNaN(y) {
+			if i == 0 {
+				y = fun(x + step)
+			}
+			if i == 100 {
+				y = fun(x - step)
+			}
+		}
+		if i == 100 {
+			x = max
+		}
+		result = append(result, Point{X: x, Y: y})
+		x += step
+	}
+
+	return result
+}
+
+// LikelihoodPlot returns the plot data for a likelihood definition over
+// the typical range of the likelihood
+func LikelihoodPlot(likelihood bayesfactor.LikelihoodDefinition) ([]Point, error) {
+
+	created, err := bayesfactor.CreateLikelihood(likelihood)
+	if err != nil {
+		return nil, err
+	}
+
+	min, max := created.TypicalRange()
+	return curve(created.Function, min, max), nil
+}
+
+// PriorPlot returns the plot data for a prior definition over the typical
+// range of the prior. Point priors are represented by a single point.
+func PriorPlot(prior bayesfactor.PriorDefinition) ([]Point, error) {
+
+	created, err := bayesfactor.CreatePrior(prior)
+	if err != nil {
+		return nil, err
+	}
+
+	min, max := created.TypicalRange()
+	if prior.Name == "point" {
+		return []Point{{X: min, Y: 1}}, nil
+	}
+	return curve(created.Function, min, max), nil
+}
+
+// likelihoodCurve returns the plot data for a likelihood, or nil if the
+// definition is invalid
+func likelihoodCurve(name string, params ...float64) []Point {
+	points, _ := LikelihoodPlot(bayesfactor.LikelihoodDefinition{Name: name, Params: params})
+	return points
+}
+
+// priorCurve returns the plot data for a prior, or nil if the definition
+// is invalid
+func priorCurve(name string, params ...float64) []Point {
+	points, _ := PriorPlot(bayesfactor.PriorDefinition{Name: name, Params: params})
+	return points
+}
+
+// DnormPlot returns the plot data for a normal likelihood
+func DnormPlot(mean float64, sd float64) []Point {
+	return likelihoodCurve("normal", mean, sd)
+}
+
+// ScaledShiftedTPlot returns the plot data for a student t likelihood
+func ScaledShiftedTPlot(mean float64, sd float64, df float64) []Point {
+	return likelihoodCurve("student_t", mean, sd, df)
+}
+
+// DbinomPlot returns the plot data for a binomial likelihood
+func DbinomPlot(successes float64, trials float64) []Point {
+	return likelihoodCurve("binomial", successes, trials)
+}
+
+// Binomial2Plot returns the plot data for a two proportion likelihood of
+// the log odds ratio
+func Binomial2Plot(successes1 float64, trials1 float64, successes2 float64, trials2 float64) []Point {
+	return likelihoodCurve("binomial2", successes1, trials1, successes2, trials2)
+}
+
+// Binomial2DiffPlot returns the plot data for a two proportion likelihood
+// of the difference in proportions
+func Binomial2DiffPlot(successes1 float64, trials1 float64, successes2 float64, trials2 float64) []Point {
+	return likelihoodCurve("binomial2_diff", successes1, trials1, successes2, trials2)
+}
+
+// CorrelationPlot returns the plot data for a correlation likelihood
+func CorrelationPlot(r float64, n float64) []Point {
+	return likelihoodCurve("correlation", r, n)
+}
+
+// CorrelationZPlot returns the plot data for a correlation likelihood with
+// the fisher z approximation
+func CorrelationZPlot(r float64, n float64) []Point {
+	return likelihoodCurve("correlation_z", r, n)
+}
+
+// PoissonPlot returns the plot data for a poisson likelihood
+func PoissonPlot(count float64, exposure float64) []Point {
+	return likelihoodCurve("poisson", count, exposure)
+}
+
+// NegativeBinomialPlot returns the plot data for a negative binomial
+// likelihood
+func NegativeBinomialPlot(count float64, size float64, exposure float64) []Point {
+	return likelihoodCurve("negative_binomial", count, size, exposure)
+}
+
+// NoncentralDPlot returns the plot data for a noncentral d likelihood
+func NoncentralDPlot(d float64, n float64) []Point {
+	return likelihoodCurve("noncentral_d", d, n)
+}
+
+// NoncentralD2Plot returns the plot data for a two sample noncentral d
+// likelihood
+func NoncentralD2Plot(d float64, n1 float64, n2 float64) []Point {
+	return likelihoodCurve("noncentral_d2", d, n1, n2)
+}
+
+// NoncentralTPlot returns the plot data for a noncentral t likelihood
+func NoncentralTPlot(t float64, df float64) []Point {
+	return likelihoodCurve("noncentral_t", t, df)
+}
+
+// DnormPriorPlot returns the plot data for a (truncated) normal prior
+func DnormPriorPlot(mean float64, sd float64, min float64, max float64) []Point {
+	return priorCurve("normal", mean, sd, min, max)
+}
+
+// StudentTPriorPlot returns the plot data for a (truncated) student t prior
+func StudentTPriorPlot(mean float64, sd float64, df float64, min float64, max float64) []Point {
+	return priorCurve("student_t", mean, sd, df, min, max)
+}
+
+// CauchyPriorPlot returns the plot data for a (truncated) cauchy prior
+func CauchyPriorPlot(location float64, scale float64, min float64, max float64) []Point {
+	return priorCurve("cauchy", location, scale, min, max)
+}
+
+// DbetaPriorPlot returns the plot data for a beta prior
+func DbetaPriorPlot(alpha float64, beta float64) []Point {
+	return priorCurve("beta", alpha, beta)
+}
+
+// UniformPriorPlot returns the plot data for a uniform prior
+func UniformPriorPlot(alpha float64, beta float64) []Point {
+	return priorCurve("uniform", alpha, beta)
+}
+
+// StretchedBetaPriorPlot returns the plot data for a (truncated)
+// stretched beta prior
+func StretchedBetaPriorPlot(alpha float64, beta float64, min float64, max float64) []Point {
+	return priorCurve("stretched_beta", alpha, beta, min, max)
+}
+
+// DgammaPriorPlot returns the plot data for a (truncated) gamma prior
+func DgammaPriorPlot(shape float64, rate float64, min float64, max float64) []Point {
+	return priorCurve("gamma", shape, rate, min, max)
+}
+
+// DlnormPriorPlot returns the plot data for a (truncated) log-normal prior
+func DlnormPriorPlot(meanlog float64, sdlog float64, min float64, max float64) []Point {
+	return priorCurve("lognormal", meanlog, sdlog, min, max)
+}
@@ -0,0 +1,200 @@
+package analysis
+
+import (
+	"math"
+
+	"pkg/bayesfactor"
+)
+
+// SampleSizePlan describes the search for the smallest sample size at which
+// a study is likely to give compelling evidence. Data under the alternative
+// are simulated with the effect (or design prior) of the plan, and data
+// under the null are simulated with effects drawn from the null prior.
+//
+// The likelihood must be noncentral_d or noncentral_d2; its sample size
+// parameters are set by the search. For noncentral_d2 the search is over
+// n1 and n2 is Ratio * n1 rounded up.
+type SampleSizePlan struct {
+	Likelihood  bayesfactor.LikelihoodDefinition `json:"likelihoodDef"`
+	AltPrior    bayesfactor.PriorDefinition      `json:"altpriorDef"`
+	NullPrior   bayesfactor.PriorDefinition      `json:"nullpriorDef"`
+	Effect      float64                          `json:"effect"`
+	DesignPrior *bayesfactor.PriorDefinition     `json:"designpriorDef,omitempty"`
+	Threshold   float64                          `json:"threshold"`
+	Simulations int                              `json:"simulations"`
+	Seed        uint64                           `json:"seed"`
+
+	// targets for P(BF10 >= Threshold | H1) and P(BF01 >= Threshold | H0).
+	// A target of 0 is always met.
+	AltTarget  float64 `json:"altTarget"`
+	NullTarget float64 `json:"nullTarget"`
+
+	// allocation ratio n2/n1 for noncentral_d2 (1 if not set)
+	Ratio float64 `json:"ratio"`
+
+	// sample sizes (n or n1) on the curve are MinN, MinN + Step, ... up to
+	// MaxN
+	MinN int `json:"minN"`
+	MaxN int `json:"maxN"`
+	Step int `json:"step"`
+}
+
+// SampleSizePoint is the probability of compelling evidence under each
+// hypothesis at one sample size. N is the total sample size; N1 and N2 are
+// only set for noncentral_d2.
+type SampleSizePoint struct {
+	N         int     `json:"n"`
+	N1        int     `json:"n1,omitempty"`
+	N2        int     `json:"n2,omitempty"`
+	AltPower  float64 `json:"altPower"`
+	NullPower float64 `json:"nullPower"`
+}
+
+// SampleSizeResult is the outcome of a sample size search. Found is false
+// if the targets aren't met by MaxN, in which case the sample size is 0.
+type SampleSizeResult struct {
+	Found bool              `json:"found"`
+	N     int               `json:"n"`
+	N1    int               `json:"n1,omitempty"`
+	N2    int               `json:"n2,omitempty"`
+	Curve []SampleSizePoint `json:"curve"`
+}
+
+// PlanSampleSize finds the smallest sample size for which the probability
+// of compelling evidence meets the targets of the plan. The probabilities
+// are first estimated on the grid from MinN to MaxN, which is returned as
+// the curve, and the sample size is then refined between the first grid
+// point that meets the targets and the grid point before it. The same
+// seed is used for every sample size so the curve is smooth and
+// reproducible.
+func PlanSampleSize(plan SampleSizePlan) (SampleSizeResult, error) {
+
+	var result SampleSizeResult
+
+	if plan.Likelihood.Name != "noncentral_d" && plan.Likelihood.Name != "noncentral_d2" {
+		return result, ErrDesign
+	}
+	if plan.Ratio == 0 {
+		plan.Ratio = 1
+	}
+	if plan.Step == 0 {
+		plan.Step = 1
+	}
+	minN := 2
+	if plan.Likelihood.Name == "noncentral_d2" {
+		minN = 1
+	}
+	if plan.MinN < minN || plan.MaxN < plan.MinN || plan.Step < 0 ||
+		!(plan.Ratio > 0) || math.IsInf(plan.Ratio, 0) ||
+		plan.AltTarget < 0 || plan.AltTarget > 1 || plan.NullTarget < 0 || plan.NullTarget > 1 {
+		return result, ErrDesign
+	}
+
+	points := make(map[int]SampleSizePoint)
+	evaluate := func(n int) (SampleSizePoint, error) {
+		if point, ok := points[n]; ok {
+			return point, nil
+		}
+		point, err := plan.evaluate(n)
+		if err != nil {
+			return point, err
+		}
+		points[n] = point
+		return point, nil
+	}
+
+	previous := plan.MinN - 1
+	found := 0
+	for n := plan.MinN; n <= plan.MaxN; n += plan.Step {
+		point, err := evaluate(n)
+		if err != nil {
+			return result, err
+		}
+		result.Curve = append(result.Curve, point)
+		if found != 0 {
+			continue
+		}
+		if plan.meets(point) {
+			found = n
+		} else {
+			previous = n
+		}
+	}
+	if found == 0 {
+		return result, nil
+	}
+
+	// refine between the grid points by bisection
+	lower, upper := previous, found
+	for upper-lower > 1 {
+		mid := (lower + upper) / 2
+		point, err := evaluate(mid)
+		if err != nil {
+			return result, err
+		}
+		if plan.meets(point) {
+			upper = mid
+		} else {
+			lower = mid
+		}
+	}
+
+	best := points[upper]
+	result.Found = true
+	result.N, result.N1, result.N2 = best.N, best.N1, best.N2
+	return result, nil
+}
+
+// evaluate estimates the probability of compelling evidence under both
+// hypotheses at sample size n (or n1)
+func (plan SampleSizePlan) evaluate(n int) (SampleSizePoint, error) {
+
+	point := SampleSizePoint{N: n}
+	likelihood := bayesfactor.LikelihoodDefinition{Name: plan.Likelihood.Name}
+	if plan.Likelihood.Name == "noncentral_d2" {
+		point.N1 = n
+		point.N2 = int(math.Ceil(plan.Ratio * float64(n)))
+		point.N = point.N1 + point.N2
+		likelihood.Params = []float64{0, float64(point.N1), float64(point.N2)}
+	} else {
+		likelihood.Params = []float64{0, float64(n)}
+	}
+
+	design := Design{
+		Likelihood:  likelihood,
+		AltPrior:    plan.AltPrior,
+		NullPrior:   plan.NullPrior,
+		Simulations: plan.Simulations,
+		Threshold:   plan.Threshold,
+		Seed:        plan.Seed,
+	}
+
+	if plan.AltTarget > 0 {
+		alt := design
+		alt.Effect = plan.Effect
+		alt.DesignPrior = plan.DesignPrior
+		result, err := Simulate(alt)
+		if err != nil {
+			return point, err
+		}
+		point.AltPower = result.AltEvidence
+	}
+
+	if plan.NullTarget > 0 {
+		null := design
+		nullPrior := plan.NullPrior
+		null.DesignPrior = &nullPrior
+		null.UnderNull = true
+		result, err := Simulate(null)
+		if err != nil {
+			return point, err
+		}
+		point.NullPower = result.NullEvidence
+	}
+
+	return point, nil
+}
+
+func (plan SampleSizePlan) meets(point SampleSizePoint) bool {
+	return point.AltPower >= plan.AltTarget && point.NullPower >= plan.NullTarget
+}
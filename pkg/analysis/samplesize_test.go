@@ -0,0 +1,72 @@
+package analysis
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"pkg/bayesfactor"
+)
+
+func TestPlanSampleSize(t *testing.T) {
+
+	inf := math.Inf(1)
+
+	plan := SampleSizePlan{
+		Likelihood:  bayesfactor.LikelihoodDefinition{Name: "noncentral_d2", Params: []float64{0, 0, 0}},
+		AltPrior:    bayesfactor.PriorDefinition{Name: "cauchy", Params: []float64{0, 0.707, -inf, inf}},
+		NullPrior:   bayesfactor.PriorDefinition{Name: "point", Params: []float64{0}},
+		Effect:      0.8,
+		Threshold:   3,
+		Simulations: 20,
+		Seed:        3,
+		AltTarget:   0.5,
+		Ratio:       2,
+		MinN:        5,
+		MaxN:        45,
+		Step:        20,
+	}
+	result, err := PlanSampleSize(plan)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	if !result.Found {
+		t.Fatalf("got %+v, wanted a sample size", result)
+	}
+	if result.N2 != 2*result.N1 || result.N != result.N1+result.N2 {
+		t.Fatalf("got n1 %v and n2 %v with a ratio of 2", result.N1, result.N2)
+	}
+
+	// the curve covers the grid and the sample size is between the grid
+	// points either side of the target
+	if len(result.Curve) != 3 {
+		t.Fatalf("got %v points on the curve, wanted 3", len(result.Curve))
+	}
+	for _, point := range result.Curve {
+		if (point.AltPower >= plan.AltTarget) != (point.N1 >= result.N1) {
+			t.Fatalf("got curve %+v for sample size %v", result.Curve, result.N1)
+		}
+	}
+
+	// n1 - 1 should not meet the target
+	below, err := plan.evaluate(result.N1 - 1)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	if result.N1 > plan.MinN && below.AltPower >= plan.AltTarget {
+		t.Fatalf("got power %v at n1 = %v", below.AltPower, result.N1-1)
+	}
+
+	// unreachable targets
+	plan.MaxN = 5
+	result, err = PlanSampleSize(plan)
+	if err != nil || result.Found || len(result.Curve) != 1 {
+		t.Fatalf("got %+v and error %v, wanted no sample size", result, err)
+	}
+
+	// only t-test likelihoods are supported
+	plan.Likelihood = bayesfactor.LikelihoodDefinition{Name: "normal", Params: []float64{0, 1}}
+	if _, err := PlanSampleSize(plan); !errors.Is(err, ErrDesign) {
+		t.Fatalf("got error %v, wanted %v", err, ErrDesign)
+	}
+}
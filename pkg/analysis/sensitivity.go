@@ -0,0 +1,136 @@
+package analysis
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+
+	"pkg/bayesfactor"
+)
+
+// ErrSensitivity is returned when a sensitivity analysis is misspecified
+var ErrSensitivity = errors.New("invalid sensitivity analysis")
+
+// SensitivityAxis is a parameter of the alternative prior, given by name
+// (e.g. "scale" for a cauchy prior), and the values it takes
+type SensitivityAxis struct {
+	Param  string    `json:"param"`
+	Values []float64 `json:"values"`
+}
+
+// SensitivityDesign varies one or two parameters of the alternative prior
+// over a grid of values while the rest of the model is held fixed
+type SensitivityDesign struct {
+	Likelihood bayesfactor.LikelihoodDefinition `json:"likelihoodDef"`
+	AltPrior   bayesfactor.PriorDefinition      `json:"altpriorDef"`
+	NullPrior  bayesfactor.PriorDefinition      `json:"nullpriorDef"`
+	Axes       []SensitivityAxis                `json:"axes"`
+}
+
+// SensitivityPoint is the Bayes factor for one combination of the varied
+// parameters. Values are in the same order as the axes. A BF10 that isn't
+// finite is encoded as null in JSON.
+type SensitivityPoint struct {
+	Values []float64 `json:"values"`
+	BF10   float64   `json:"bf10"`
+}
+
+// MarshalJSON encodes the point, with null in place of a BF10 that isn't
+// finite
+func (p SensitivityPoint) MarshalJSON() ([]byte, error) {
+	type plain SensitivityPoint
+	return json.Marshal(struct {
+		plain
+		BF10 *float64 `json:"bf10"`
+	}{plain(p), bayesfactor.Finite(p.BF10)})
+}
+
+// SensitivityResult is the table of Bayes factors over the grid. The table
+// is ordered with the last axis varying fastest. Min and Max are the points
+// with the smallest and largest BF10, ignoring undefined Bayes factors, and
+// are empty if there are none.
+type SensitivityResult struct {
+	Params []string           `json:"params"`
+	Table  []SensitivityPoint `json:"table"`
+	Min    SensitivityPoint   `json:"min"`
+	Max    SensitivityPoint   `json:"max"`
+}
+
+// Sensitivity computes the Bayes factor for every combination of values of
+// the varied prior parameters
+func Sensitivity(design SensitivityDesign) (SensitivityResult, error) {
+
+	var result SensitivityResult
+
+	if len(design.Axes) < 1 || len(design.Axes) > 2 {
+		return result, ErrSensitivity
+	}
+
+	// find the position of each varied parameter in the prior
+	names := bayesfactor.PriorParams(design.AltPrior.Name)
+	index := make([]int, len(design.Axes))
+	for i, axis := range design.Axes {
+		index[i] = -1
+		for j, name := range names {
+			if name == axis.Param {
+				index[i] = j
+			}
+		}
+		if index[i] < 0 || len(axis.Values) == 0 {
+			return result, ErrSensitivity
+		}
+		if i > 0 && index[i] == index[0] {
+			return result, ErrSensitivity
+		}
+		result.Params = append(result.Params, axis.Param)
+	}
+
+	// a valid prior only leaves out its bounds, so the default bounds fill
+	// in the parameters before they are set by position
+	if _, err := bayesfactor.CreatePrior(design.AltPrior); err != nil {
+		return result, err
+	}
+	params := append([]float64(nil), design.AltPrior.Params...)
+	params = append(params, math.Inf(-1), math.Inf(1))[:len(names)]
+
+	var grid [][]float64
+	for _, x := range design.Axes[0].Values {
+		if len(design.Axes) == 1 {
+			grid = append(grid, []float64{x})
+			continue
+		}
+		for _, y := range design.Axes[1].Values {
+			grid = append(grid, []float64{x, y})
+		}
+	}
+
+	for _, values := range grid {
+		altprior := design.AltPrior
+		altprior.Params = append([]float64(nil), params...)
+		for i, value := range values {
+			altprior.Params[index[i]] = value
+		}
+
+		bf, err := bayesfactor.Bayesfactor(design.Likelihood, altprior, design.NullPrior)
+		if err != nil {
+			return result, err
+		}
+		result.Table = append(result.Table, SensitivityPoint{Values: values, BF10: bf})
+	}
+
+	found := false
+	for _, point := range result.Table {
+		if math.IsNaN(point.BF10) {
+			continue
+		}
+		if !found || point.BF10 < result.Min.BF10 {
+			result.Min = point
+		}
+		if !found || point.BF10 > result.Max.BF10 {
+			result.Max = point
+		}
+		found = true
+	}
+
+	return result, nil
+}
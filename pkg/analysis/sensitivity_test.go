@@ -0,0 +1,136 @@
+package analysis
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+
+	"pkg/bayesfactor"
+)
+
+func TestSensitivity(t *testing.T) {
+
+	inf := math.Inf(1)
+
+	// normal likelihood with a normal prior:
+	// BF10 = dnorm(5, 0, sqrt(100 + sd^2)) / dnorm(5, 0, 10)
+	design := SensitivityDesign{
+		Likelihood: bayesfactor.LikelihoodDefinition{Name: "normal", Params: []float64{5, 10}},
+		AltPrior:   bayesfactor.PriorDefinition{Name: "normal", Params: []float64{0, 1, -inf, inf}},
+		NullPrior:  bayesfactor.PriorDefinition{Name: "point", Params: []float64{0}},
+		Axes:       []SensitivityAxis{{Param: "sd", Values: []float64{1, 5, 10, 20}}},
+	}
+	result, err := Sensitivity(design)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	want := []float64{0.9962694, 0.9170697, 0.7527113, 0.4942475}
+	if len(result.Table) != len(want) {
+		t.Fatalf("got %v points, wanted %v", len(result.Table), len(want))
+	}
+	for i, point := range result.Table {
+		compare(t, point.BF10, want[i])
+	}
+	if result.Max.Values[0] != 1 || result.Min.Values[0] != 20 {
+		t.Fatalf("got max at %v and min at %v", result.Max.Values, result.Min.Values)
+	}
+	if design.AltPrior.Params[1] != 1 {
+		t.Fatalf("prior changed to %v", design.AltPrior.Params)
+	}
+
+	// two parameters
+	design.Axes = []SensitivityAxis{
+		{Param: "mean", Values: []float64{0, 5}},
+		{Param: "sd", Values: []float64{1, 5, 10}},
+	}
+	result, err = Sensitivity(design)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	if len(result.Table) != 6 || result.Table[4].Values[0] != 5 || result.Table[4].Values[1] != 5 {
+		t.Fatalf("got table %+v", result.Table)
+	}
+	if result.Params[0] != "mean" || result.Max.Values[0] != 5 || result.Max.Values[1] != 1 {
+		t.Fatalf("got max at %v", result.Max.Values)
+	}
+
+	// a beta prior without its optional bounds:
+	// BF10 = beta(8 + alpha, 4) / beta(alpha, 1) / 0.5^11
+	var beta SensitivityDesign
+	err = json.Unmarshal([]byte(`{
+		"likelihoodDef": {"distribution": "binomial", "parameters": {"successes": 8, "trials": 11}},
+		"altpriorDef": {"distribution": "beta", "parameters": {"alpha": 1, "beta": 1}},
+		"nullpriorDef": {"distribution": "point", "parameters": {"point": 0.5}},
+		"axes": [{"param": "alpha", "values": [1, 2.5]}]
+	}`), &beta)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	if len(beta.AltPrior.Params) != 2 {
+		t.Fatalf("got prior %v, wanted 2 parameters", beta.AltPrior.Params)
+	}
+	result, err = Sensitivity(beta)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	if len(result.Table) != 2 {
+		t.Fatalf("got table %+v", result.Table)
+	}
+	compare(t, result.Table[0].BF10, 1.034343)
+	compare(t, result.Table[1].BF10, 1/0.6632996)
+
+	// unknown parameter
+	design.Axes = []SensitivityAxis{{Param: "scale", Values: []float64{1}}}
+	if _, err := Sensitivity(design); !errors.Is(err, ErrSensitivity) {
+		t.Fatalf("got error %v, wanted %v", err, ErrSensitivity)
+	}
+
+	// invalid prior
+	design.AltPrior = bayesfactor.PriorDefinition{Name: "normal", Params: []float64{0, 1, 0}}
+	design.Axes = []SensitivityAxis{{Param: "sd", Values: []float64{1}}}
+	if _, err := Sensitivity(design); !errors.Is(err, bayesfactor.ErrParamCount) {
+		t.Fatalf("got error %v, wanted %v", err, bayesfactor.ErrParamCount)
+	}
+}
+
+func TestSensitivityLargeBf(t *testing.T) {
+
+	// BF10 = exp(400) / sqrt(2) with the prior at 0, and overflows a
+	// float64 with the prior at the observation
+	design := SensitivityDesign{
+		Likelihood: bayesfactor.LikelihoodDefinition{Name: "normal", Params: []float64{40, 1}},
+		AltPrior:   bayesfactor.PriorDefinition{Name: "normal", Params: []float64{0, 1, math.Inf(-1), math.Inf(1)}},
+		NullPrior:  bayesfactor.PriorDefinition{Name: "point", Params: []float64{0}},
+		Axes:       []SensitivityAxis{{Param: "mean", Values: []float64{0, 40}}},
+	}
+	result, err := Sensitivity(design)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	if math.IsInf(result.Table[0].BF10, 0) || !math.IsInf(result.Table[1].BF10, 1) {
+		t.Fatalf("got table %+v, wanted a finite and an infinite BF10", result.Table)
+	}
+	compare(t, math.Log(result.Table[0].BF10), 400-math.Log(2)/2)
+	if result.Min.Values[0] != 0 || result.Max.Values[0] != 40 {
+		t.Fatalf("got min at %v and max at %v", result.Min.Values, result.Max.Values)
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	var decoded struct {
+		Table []map[string]interface{} `json:"table"`
+		Max   map[string]interface{}   `json:"max"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	if bf, ok := decoded.Max["bf10"]; !ok || bf != nil {
+		t.Fatalf("got max bf10 %v, wanted null", bf)
+	}
+	if bf, _ := decoded.Table[0]["bf10"].(float64); bf != result.Table[0].BF10 {
+		t.Fatalf("got bf10 %v, wanted %v", decoded.Table[0]["bf10"], result.Table[0].BF10)
+	}
+}
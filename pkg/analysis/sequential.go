@@ -0,0 +1,140 @@
+package analysis
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"strconv"
+
+	"pkg/bayesfactor"
+)
+
+// ErrSequence is returned when a sequence of observations can't be
+// monitored
+var ErrSequence = errors.New("invalid sequence")
+
+// SequentialDesign is a sequence of looks at accumulating data. Each step
+// is the likelihood at one look, e.g. the t or d value with the current
+// sample size, or the cumulative successes and trials for binomial data.
+// All steps must use the same likelihood family. Monitoring stops when
+// BF10 or BF01 first reaches Threshold (DefaultThreshold if not set).
+type SequentialDesign struct {
+	Steps     []bayesfactor.LikelihoodDefinition `json:"steps"`
+	AltPrior  bayesfactor.PriorDefinition        `json:"altpriorDef"`
+	NullPrior bayesfactor.PriorDefinition        `json:"nullpriorDef"`
+	Threshold float64                            `json:"threshold"`
+}
+
+// SequentialStep is the Bayes factor at one look. Bayes factors that
+// aren't finite are encoded as null in JSON.
+type SequentialStep struct {
+	Likelihood bayesfactor.LikelihoodDefinition `json:"likelihoodDef"`
+	BF10       float64                          `json:"bf10"`
+	BF01       float64                          `json:"bf01"`
+	LogBF10    float64                          `json:"logbf10"`
+}
+
+// MarshalJSON encodes the step, with null in place of Bayes factors that
+// aren't finite
+func (s SequentialStep) MarshalJSON() ([]byte, error) {
+	type plain SequentialStep
+	return json.Marshal(struct {
+		plain
+		BF10    *float64 `json:"bf10"`
+		BF01    *float64 `json:"bf01"`
+		LogBF10 *float64 `json:"logbf10"`
+	}{plain(s), bayesfactor.Finite(s.BF10), bayesfactor.Finite(s.BF01), bayesfactor.Finite(s.LogBF10)})
+}
+
+// SequentialResult is the trajectory of Bayes factors over the steps of a
+// SequentialDesign. Stop is the index of the first step at which the
+// threshold is reached (-1 if it never is) and Decision is the model
+// favoured at that step (AltModel or NullModel). The Bayes factor is
+// computed at every step, including those after the stopping point.
+type SequentialResult struct {
+	Trajectory []SequentialStep `json:"trajectory"`
+	Threshold  float64          `json:"threshold"`
+	Stop       int              `json:"stop"`
+	Decision   string           `json:"decision"`
+}
+
+// Sequential computes the Bayes factor at each step of a sequential design
+// and finds the first step at which the stopping threshold is reached
+func Sequential(design SequentialDesign) (SequentialResult, error) {
+
+	result := SequentialResult{Stop: -1}
+
+	if design.Threshold == 0 {
+		design.Threshold = DefaultThreshold
+	}
+	if len(design.Steps) == 0 || !(design.Threshold >= 1) || math.IsInf(design.Threshold, 0) {
+		return result, ErrSequence
+	}
+	result.Threshold = design.Threshold
+
+	for i, likelihood := range design.Steps {
+		if likelihood.Name != design.Steps[0].Name {
+			return result, ErrSequence
+		}
+
+		logbf, err := bayesfactor.LogBayesfactor(likelihood, design.AltPrior, design.NullPrior)
+		if err != nil {
+			return result, err
+		}
+
+		result.Trajectory = append(result.Trajectory, SequentialStep{
+			Likelihood: likelihood,
+			BF10:       math.Exp(logbf),
+			BF01:       math.Exp(-logbf),
+			LogBF10:    logbf,
+		})
+
+		if result.Stop >= 0 {
+			continue
+		}
+		if logbf >= math.Log(design.Threshold) {
+			result.Stop, result.Decision = i, AltModel
+		} else if -logbf >= math.Log(design.Threshold) {
+			result.Stop, result.Decision = i, NullModel
+		}
+	}
+
+	return result, nil
+}
+
+// WriteCSV writes the trajectory as CSV with a header row. The columns are
+// the step number (starting at 1), the likelihood parameters by name, and
+// the Bayes factors.
+func (r SequentialResult) WriteCSV(w io.Writer) error {
+
+	var names []string
+	if len(r.Trajectory) > 0 {
+		names = bayesfactor.LikelihoodParams(r.Trajectory[0].Likelihood.Name)
+	}
+
+	writer := csv.NewWriter(w)
+	header := append([]string{"step"}, names...)
+	header = append(header, "bf10", "bf01", "logbf10")
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	format := func(x float64) string {
+		return strconv.FormatFloat(x, 'g', -1, 64)
+	}
+	for i, step := range r.Trajectory {
+		record := []string{strconv.Itoa(i + 1)}
+		for _, param := range step.Likelihood.Params {
+			record = append(record, format(param))
+		}
+		record = append(record, format(step.BF10), format(step.BF01), format(step.LogBF10))
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,98 @@
+package analysis
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+
+	"pkg/bayesfactor"
+)
+
+func TestSequential(t *testing.T) {
+
+	binomial := func(successes, trials float64) bayesfactor.LikelihoodDefinition {
+		return bayesfactor.LikelihoodDefinition{Name: "binomial", Params: []float64{successes, trials}}
+	}
+
+	// with a uniform prior BF10 = 2^n / ((n + 1) * choose(n, k))
+	design := SequentialDesign{
+		Steps:     []bayesfactor.LikelihoodDefinition{binomial(5, 6), binomial(9, 10), binomial(14, 15), binomial(15, 20)},
+		AltPrior:  bayesfactor.PriorDefinition{Name: "beta", Params: []float64{1, 1}},
+		NullPrior: bayesfactor.PriorDefinition{Name: "point", Params: []float64{0.5}},
+	}
+	result, err := Sequential(design)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	if len(result.Trajectory) != 4 {
+		t.Fatalf("got %v steps, wanted 4", len(result.Trajectory))
+	}
+	compare(t, result.Trajectory[0].BF10, 64.0/42)
+	compare(t, result.Trajectory[1].BF10, 1024.0/110)
+	compare(t, result.Trajectory[2].BF10, 32768.0/240)
+	if result.Stop != 2 || result.Decision != AltModel {
+		t.Fatalf("got stop %v (%v), wanted 2", result.Stop, result.Decision)
+	}
+
+	var buf bytes.Buffer
+	if err := result.WriteCSV(&buf); err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	if len(records) != 5 || len(records[0]) != 6 || records[0][1] != "successes" || records[3][0] != "3" || records[3][2] != "15" {
+		t.Fatalf("got csv %v", records)
+	}
+
+	// mixed likelihood families
+	design.Steps[3] = bayesfactor.LikelihoodDefinition{Name: "normal", Params: []float64{0, 1}}
+	if _, err := Sequential(design); !errors.Is(err, ErrSequence) {
+		t.Fatalf("got error %v, wanted %v", err, ErrSequence)
+	}
+}
+
+func TestSequentialLargeBf(t *testing.T) {
+
+	// BF10 overflows a float64 at the last step
+	design := SequentialDesign{
+		Steps: []bayesfactor.LikelihoodDefinition{
+			{Name: "noncentral_d", Params: []float64{1, 30}},
+			{Name: "noncentral_d", Params: []float64{1, 3000}},
+		},
+		AltPrior:  bayesfactor.PriorDefinition{Name: "cauchy", Params: []float64{0, 0.707, math.Inf(-1), math.Inf(1)}},
+		NullPrior: bayesfactor.PriorDefinition{Name: "point", Params: []float64{0}},
+	}
+	result, err := Sequential(design)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	last := result.Trajectory[1]
+	if !math.IsInf(last.BF10, 1) || last.BF01 != 0 {
+		t.Fatalf("got bf10 %v and bf01 %v, wanted +Inf and 0", last.BF10, last.BF01)
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	var decoded struct {
+		Trajectory []map[string]interface{} `json:"trajectory"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	if bf, ok := decoded.Trajectory[1]["bf10"]; !ok || bf != nil {
+		t.Fatalf("got bf10 %v, wanted null", bf)
+	}
+	if bf, _ := decoded.Trajectory[0]["bf10"].(float64); bf != result.Trajectory[0].BF10 {
+		t.Fatalf("got bf10 %v, wanted %v", decoded.Trajectory[0]["bf10"], result.Trajectory[0].BF10)
+	}
+	if logbf, _ := decoded.Trajectory[1]["logbf10"].(float64); logbf != last.LogBF10 {
+		t.Fatalf("got logbf10 %v, wanted %v", decoded.Trajectory[1]["logbf10"], last.LogBF10)
+	}
+}
@@ -3,113 +3,176 @@ package bayesfactor
 import (
 	"math"
 
+	"golang.org/x/exp/rand"
+
 	. "pkg/distributions"
 )
 
-func CreateLikelihood(likelihood LikelihoodDefinition) Likelihood {
-
-	var data Likelihood
-
-	switch likelihood.Name {
-	case "noncentral_d":
-		d := likelihood.Params[0]
-		n := likelihood.Params[1]
-		fun := NoncentralDLikelihood(d, n)
-		data.Function = fun
-		data.Name = "noncentral_d"
-
-	case "noncentral_d2":
-		d := likelihood.Params[0]
-		n1 := likelihood.Params[1]
-		n2 := likelihood.Params[2]
-		fun := NoncentralD2Likelihood(d, n1, n2)
-		data.Function = fun
-		data.Name = "noncentral_d2"
-
-	case "normal":
-		mean := likelihood.Params[0]
-		sd := likelihood.Params[1]
-		fun := NormalLikelihood(mean, sd)
-		data.Function = fun
-		data.Name = "normal"
-
-	case "binomial":
-		successes := likelihood.Params[0]
-		trials := likelihood.Params[1]
-		data.Function = BinomialLikelihood(successes, trials)
-		data.Name = "binomial"
-
-	case "noncentral_t":
-		t := likelihood.Params[0]
-		df := likelihood.Params[1]
-		// fun := Noncentral_t_likelihood(t, df)
-		data.Function = NoncentralTLikelihood(t, df)
-		data.Name = "noncentral_t"
-
-	case "student_t":
-		mean := likelihood.Params[0]
-		sd := likelihood.Params[1]
-		df := likelihood.Params[2]
-		fun := StudentTLikelihood(mean, sd, df)
-		data.Function = fun
-		data.Name = "student_t"
-	}
-
-	return data
-}
-
-func CreatePrior(priorDefinition PriorDefinition) Prior {
-	var prior Prior
+// CreateLikelihood validates a likelihood definition and builds the
+// corresponding likelihood function using its registered family
+func CreateLikelihood(likelihood LikelihoodDefinition) (Likelihood, error) {
 
-	switch priorDefinition.Name {
-	case "cauchy":
-		location := priorDefinition.Params[0]
-		scale := priorDefinition.Params[1]
-		min := priorDefinition.Params[2]
-		max := priorDefinition.Params[3]
-		prior = CauchyPrior(location, scale, min, max)
-
-	case "normal":
-		mean := priorDefinition.Params[0]
-		sd := priorDefinition.Params[1]
-		min := priorDefinition.Params[2]
-		max := priorDefinition.Params[3]
-		prior = NormalPrior(mean, sd, min, max)
-
-	case "beta":
-		alpha := priorDefinition.Params[0]
-		beta := priorDefinition.Params[1]
-		prior = BetaPrior(alpha, beta, 0, 1)
-
-	case "uniform":
-		alpha := priorDefinition.Params[0]
-		beta := priorDefinition.Params[1]
-		prior = UniformPrior(alpha, beta)
-
-	case "student_t":
-		mean := priorDefinition.Params[0]
-		sd := priorDefinition.Params[1]
-		df := priorDefinition.Params[2]
-		min := priorDefinition.Params[3]
-		max := priorDefinition.Params[4]
-		prior = StudentTPrior(mean, sd, df, min, max)
-
-	case "point":
-		point := priorDefinition.Params[0]
-		prior = PointPrior(point)
+	if err := validateLikelihood(likelihood); err != nil {
+		return Likelihood{}, err
 	}
 
-	return prior
+	data := likelihoodFamily(likelihood.Name).likelihood(likelihood.Params)
+	data.Name = likelihood.Name
+	return data, nil
+}
+
+// CreatePrior validates a prior definition and builds the corresponding
+// prior using its registered family. Families with min and max parameters
+// are truncated to them.
+func CreatePrior(priorDefinition PriorDefinition) (Prior, error) {
+
+	if err := validatePrior(priorDefinition); err != nil {
+		return Prior{}, err
+	}
+
+	family := priorFamily(priorDefinition.Name)
+	params := family.complete(priorDefinition.Params)
+	prior := family.prior(params)
+	prior.Name = priorDefinition.Name
+	if !family.bounded() {
+		return prior, prior.err
+	}
+
+	n := len(params)
+	prior = Truncate(prior, params[n-2], params[n-1])
+	return prior, prior.err
+}
+
+// SupportPrior returns the prior with its infinite min and max bounds
+// replaced by the bounds of the support of the likelihood, so that, as in
+// the web app, a prior under a binomial likelihood is truncated to [0, 1]
+// and normalized there. Pp uses it for every prior. Priors without bounds
+// and invalid definitions are returned unchanged.
+func SupportPrior(prior PriorDefinition, likelihood LikelihoodDefinition) PriorDefinition {
+
+	family := priorFamily(prior.Name)
+	if family == nil || !family.bounded() {
+		return prior
+	}
+	created, err := CreateLikelihood(likelihood)
+	if err != nil {
+		return prior
+	}
+	return supportPrior(prior, family, created)
+}
+
+// supportPrior restricts the bounds of a prior from a bounded family to
+// the support of likelihood
+func supportPrior(prior PriorDefinition, family *entry, likelihood Likelihood) PriorDefinition {
+
+	params := family.complete(prior.Params)
+	n := len(params)
+	if n != len(family.params) {
+		return prior
+	}
+	min, max := likelihood.Support()
+
+	params = append([]float64(nil), params...)
+	if math.IsInf(params[n-2], -1) {
+		params[n-2] = min
+	}
+	if math.IsInf(params[n-1], 1) {
+		params[n-1] = max
+	}
+	return PriorDefinition{Name: prior.Name, Params: params}
 }
 
+// Options control how marginal likelihoods are computed
+type Options struct {
+	// Quadrature sets the tolerances for the adaptive integration of the
+	// product of the likelihood and the prior
+	Quadrature Quadrature
+
+	// Numeric forces numerical integration for models that have a closed
+	// form marginal likelihood
+	Numeric bool
+}
+
+// DefaultOptions are the options used by Pp and Bayesfactor
+var DefaultOptions = Options{
+	Quadrature: DefaultQuadrature,
+}
+
+// Bayesfactor computes the Bayes factor (BF10) comparing the alternative
+// prior to the null prior. An error is returned if any of the definitions
+// are invalid or if the ratio of the marginal likelihoods is undefined.
 func Bayesfactor(likelihood LikelihoodDefinition, altprior PriorDefinition, nullprior PriorDefinition) (float64, error) {
+	return BayesfactorWithOptions(likelihood, altprior, nullprior, DefaultOptions)
+}
+
+// BayesfactorWithOptions computes the Bayes factor like Bayesfactor using
+// the given options
+func BayesfactorWithOptions(likelihood LikelihoodDefinition, altprior PriorDefinition, nullprior PriorDefinition, opts Options) (float64, error) {
+	logbf, err := LogBayesfactorWithOptions(likelihood, altprior, nullprior, opts)
+	return math.Exp(logbf), err
+}
+
+// LogBayesfactor computes the natural log of the Bayes factor (BF10) from
+// the log marginal likelihoods, so it is finite even when the Bayes factor
+// or the marginal likelihoods are too large or small to represent
+func LogBayesfactor(likelihood LikelihoodDefinition, altprior PriorDefinition, nullprior PriorDefinition) (float64, error) {
+	return LogBayesfactorWithOptions(likelihood, altprior, nullprior, DefaultOptions)
+}
+
+// LogBayesfactorWithOptions computes the log Bayes factor like
+// LogBayesfactor using the given options
+func LogBayesfactorWithOptions(likelihood LikelihoodDefinition, altprior PriorDefinition, nullprior PriorDefinition, opts Options) (float64, error) {
+	comparison, err := CompareWithOptions(likelihood, altprior, nullprior, opts)
+	return comparison.LogBF10, err
+}
 
+// Comparison holds the marginal likelihoods of the alternative and null
+// models and the log Bayes factor (BF10) comparing them
+type Comparison struct {
+	Alt     Predictive
+	Null    Predictive
+	LogBF10 float64
+}
+
+// BF10 is the Bayes factor, which is +Inf if it overflows
+func (c Comparison) BF10() float64 {
+	return math.Exp(c.LogBF10)
+}
+
+// BF01 is the inverse of the Bayes factor
+func (c Comparison) BF01() float64 {
+	return math.Exp(-c.LogBF10)
+}
+
+// Compare computes the marginal likelihoods of the alternative and null
+// models and the log Bayes factor, so that callers that need both don't
+// integrate twice
+func Compare(likelihood LikelihoodDefinition, altprior PriorDefinition, nullprior PriorDefinition) (Comparison, error) {
+	return CompareWithOptions(likelihood, altprior, nullprior, DefaultOptions)
+}
+
+// CompareWithOptions compares the models like Compare using the given
+// options. LogBF10 is NaN and ErrUndefinedRatio is returned if the ratio
+// of the marginal likelihoods is undefined.
+func CompareWithOptions(likelihood LikelihoodDefinition, altprior PriorDefinition, nullprior PriorDefinition, opts Options) (Comparison, error) {
+
+	comparison := Comparison{LogBF10: math.NaN()}
+	var err error
 
-	altModel := Pp(likelihood, altprior)
-	nullModel := Pp(likelihood, nullprior)
+	comparison.Alt, err = PpWithOptions(likelihood, altprior, opts)
+	if err != nil {
+		return comparison, err
+	}
+	comparison.Null, err = PpWithOptions(likelihood, nullprior, opts)
+	if err != nil {
+		return comparison, err
+	}
 
-	bf := altModel.Auc / nullModel.Auc
-	return bf, nil
+	comparison.LogBF10 = comparison.Alt.LogAuc - comparison.Null.LogAuc
+	if math.IsNaN(comparison.LogBF10) {
+		return comparison, ErrUndefinedRatio
+	}
+	return comparison, nil
 }
 
 // Types
@@ -128,23 +191,47 @@ type PriorDefinition struct {
 // Predctive type
 
 type Predictive struct {
-	Function   func(x float64) float64
-	Auc        float64
-	Likelihood func(x float64) float64
-	Prior      func(x float64) float64
+	Function    func(x float64) float64
+	LogFunction func(x float64) float64
+	Auc         float64
+	LogAuc      float64
+	AucError    float64 // estimated absolute error of Auc
+	Likelihood  func(x float64) float64
+	Prior       func(x float64) float64
+	isPoint     bool      // the prior is a point prior
+	point       float64   // location of the point prior
+	min         float64   // lower bound of the posterior support
+	max         float64   // upper bound of the posterior support
+	breaks      []float64 // peaks of the likelihood and prior
 }
 
 // Prior type
 type Prior struct {
-	Function func(x float64) float64
-	Name     string
-	point    float64 // this is only used for the point prior because floating point :(
+	Function    func(x float64) float64
+	LogFunction func(x float64) float64
+	Name        string
+	point       float64 // this is only used for the point prior because floating point :(
+	min         float64 // lower bound of the support
+	max         float64 // upper bound of the support
+	center      float64 // location of the bulk of the prior
+	scale       float64 // width of the bulk of the prior
+	err         error   // error normalizing a truncated prior
+	sampler     func(src rand.Source) float64
+	dist        *distribution // the untruncated distribution, if known
+	bounded     bool          // min and max have been set
 }
 
 // Likelihood type
 type Likelihood struct {
-	Function func(x float64) float64
-	Name     string
+	Function    func(x float64) float64
+	LogFunction func(x float64) float64
+	Name        string
+	center      float64                 // location of the peak of the likelihood
+	scale       float64                 // width of the peak of the likelihood
+	min         float64                 // lower bound of the support
+	max         float64                 // upper bound of the support
+	bounded     bool                    // min and max have been set
+	quantile    func(p float64) float64 // quantiles of the normalized likelihood
 }
 
 // Helper functions
@@ -157,41 +244,265 @@ func inrange(x float64, min float64, max float64) float64 {
 	return 0
 }
 
+func logInrange(x float64, min float64, max float64) float64 {
+	return math.Log(inrange(x, min, max))
+}
+
+// Rand draws a value from the prior using src, respecting the bounds of
+// truncated priors
+func (prior Prior) Rand(src rand.Source) float64 {
+	if prior.sampler == nil {
+		return math.NaN()
+	}
+	return prior.sampler(src)
+}
+
+// Support returns the bounds of the prior. Priors that weren't built by
+// this package are unbounded unless they have been truncated.
+func (prior Prior) Support() (float64, float64) {
+	if !prior.bounded {
+		return math.Inf(-1), math.Inf(1)
+	}
+	return prior.min, prior.max
+}
+
+// TypicalRange returns the range that holds the bulk of the prior: the
+// median plus or minus four robust standard deviations (the interquartile
+// range divided by that of the standard normal), within the support. For
+// a normal prior this is the mean plus or minus four standard deviations,
+// and unlike a central interval it stays narrow for heavy tailed priors
+// such as the cauchy. A point prior's range is the point.
+func (prior Prior) TypicalRange() (float64, float64) {
+
+	min, max := prior.Support()
+	switch {
+	case prior.Name == "point":
+		return prior.point, prior.point
+	case prior.dist != nil:
+		dist := *prior.dist
+		return typicalRange(func(p float64) float64 {
+			return Qtruncated(p, dist.cdf, dist.quantile, min, max)
+		}, min, max)
+	case prior.scale > 0:
+		return math.Max(min, prior.center-4*prior.scale), math.Min(max, prior.center+4*prior.scale)
+	}
+	return min, max
+}
+
+// Support returns the bounds of the parameter of the likelihood. Like
+// priors, likelihoods that weren't built by this package are unbounded.
+func (likelihood Likelihood) Support() (float64, float64) {
+	if !likelihood.bounded {
+		return math.Inf(-1), math.Inf(1)
+	}
+	return likelihood.min, likelihood.max
+}
+
+// TypicalRange returns the range of parameter values that the data
+// support, defined like Prior.TypicalRange for the likelihood normalized
+// to a density. The noncentral t likelihoods use a normal approximation.
+func (likelihood Likelihood) TypicalRange() (float64, float64) {
+
+	min, max := likelihood.Support()
+	switch {
+	case likelihood.quantile != nil:
+		return typicalRange(likelihood.quantile, min, max)
+	case likelihood.scale > 0:
+		return math.Max(min, likelihood.center-4*likelihood.scale), math.Min(max, likelihood.center+4*likelihood.scale)
+	}
+	return min, max
+}
+
+// normalIQR is the interquartile range of the standard normal distribution
+var normalIQR = 2 * Qnorm(0.75, 0, 1, true, false)
+
+// typicalRange returns the median plus or minus four robust standard
+// deviations of the distribution with the given quantile function, within
+// [min, max]
+func typicalRange(quantile func(p float64) float64, min float64, max float64) (float64, float64) {
+	median := quantile(0.5)
+	sd := (quantile(0.75) - quantile(0.25)) / normalIQR
+	return math.Max(min, median-4*sd), math.Min(max, median+4*sd)
+}
+
+// noncentralQuantile returns the quantiles of the normal approximation to
+// the normalized likelihood of an effect size with noncentrality parameter
+// effect / scale, given the observed t statistic: the likelihood of the
+// noncentrality parameter has a standard deviation of about
+// sqrt(1 + t^2 / 2df)
+func noncentralQuantile(t float64, df float64, scale float64) func(p float64) float64 {
+	center := t * scale
+	sd := math.Sqrt(1+t*t/(2*df)) * scale
+	return func(p float64) float64 {
+		return Qnorm(p, center, sd, true, false)
+	}
+}
+
+// Truncate restricts a prior to [min, max] (within its current support)
+// and renormalizes it. Priors built by this package are renormalized
+// exactly from their CDF. Other priors are renormalized by integrating
+// their density and are sampled by rejection. A point prior is unchanged
+// if the point is within the bounds. The error of the prior returned by
+// CreatePrior is set if there is no mass between the bounds.
+func Truncate(prior Prior, min float64, max float64) Prior {
+
+	lower, upper := prior.Support()
+	min, max = math.Max(min, lower), math.Min(max, upper)
+	noMass := &ModelError{Kind: "prior", Family: prior.Name, Param: "min/max", Err: ErrNoMass}
+
+	switch {
+	case prior.Name == "point":
+		if !(prior.point >= min && prior.point <= max) {
+			prior.err = noMass
+		}
+		return prior
+
+	case prior.dist != nil:
+		truncated := truncate(prior.Name, *prior.dist, min, max)
+		truncated.center, truncated.scale = prior.center, prior.scale
+		if prior.err != nil {
+			truncated.err = prior.err
+		}
+		return truncated
+	}
+
+	logDensity := prior.LogFunction
+	if logDensity == nil {
+		logDensity = func(x float64) float64 { return math.Log(prior.Function(x)) }
+	}
+	integral, err := DefaultQuadrature.IntegrateLog(func(x float64) float64 {
+		return logDensity(x) + logInrange(x, min, max)
+	}, min, max, prior.center)
+	logMass := integral.LogValue
+
+	truncated := prior
+	truncated.dist = nil
+	truncated.min, truncated.max, truncated.bounded = min, max, true
+	switch {
+	case !(min < max) || math.IsInf(logMass, 0) || math.IsNaN(logMass):
+		truncated.err = noMass
+	case err != nil:
+		truncated.err = err
+	}
+	logFunction := func(x float64) float64 {
+		return logDensity(x) + logInrange(x, min, max) - logMass
+	}
+	truncated.LogFunction = logFunction
+	truncated.Function = func(x float64) float64 {
+		return math.Exp(logFunction(x))
+	}
+	if prior.sampler != nil {
+		truncated.sampler = func(src rand.Source) float64 {
+			for i := 0; i < maxRejections; i++ {
+				if x := prior.sampler(src); x >= min && x <= max {
+					return x
+				}
+			}
+			return math.NaN()
+		}
+	}
+	return truncated
+}
+
+// maximum number of draws used to sample from a truncated prior by
+// rejection
+const maxRejections = 10000
+
 func mult(likelihood func(x float64) float64, prior func(x float64) float64) func(x float64) float64 {
 	return func(x float64) float64 {
 		return likelihood(x) * prior(x)
 	}
 }
 
-func Pp(likelihoodDef LikelihoodDefinition, priorDef PriorDefinition) Predictive {
+// breakpoints returns the points used to split the range of integration
+// so that the peaks of the likelihood and prior aren't missed
+func breakpoints(likelihood Likelihood, prior Prior) []float64 {
+	var points []float64
+	for _, k := range []float64{-20, -10, -5, -2, -1, 0, 1, 2, 5, 10, 20} {
+		points = append(points, likelihood.center+k*likelihood.scale)
+	}
+	for _, k := range []float64{-5, -1, 0, 1, 5} {
+		points = append(points, prior.center+k*prior.scale)
+	}
+
+	finite := points[:0]
+	for _, x := range points {
+		if !math.IsNaN(x) && !math.IsInf(x, 0) {
+			finite = append(finite, x)
+		}
+	}
+	return finite
+}
+
+// Pp computes the marginal likelihood (prior predictive) of the data
+// under the prior. The log of the marginal likelihood is in LogAuc.
+func Pp(likelihoodDef LikelihoodDefinition, priorDef PriorDefinition) (Predictive, error) {
+	return PpWithOptions(likelihoodDef, priorDef, DefaultOptions)
+}
+
+// PpWithOptions computes the marginal likelihood like Pp using the given
+// options. Conjugate models use the closed form marginal likelihood unless
+// opts.Numeric is set. Otherwise the product of the likelihood and prior is
+// integrated over the support of the prior with adaptive quadrature and an
+// error is returned if the tolerance can't be met.
+func PpWithOptions(likelihoodDef LikelihoodDefinition, priorDef PriorDefinition, opts Options) (Predictive, error) {
 
-	likelihood := CreateLikelihood(likelihoodDef)
-	prior := CreatePrior(priorDef)
-	var prod func(x float64) float64
-	likelihoodFunction := likelihood.Function
-	prod = mult(likelihoodFunction, prior.Function)
 	var pred Predictive
+	likelihood, err := CreateLikelihood(likelihoodDef)
+	if err != nil {
+		return pred, err
+	}
+	if family := priorFamily(priorDef.Name); family != nil && family.bounded() {
+		priorDef = supportPrior(priorDef, family, likelihood)
+	}
+	prior, err := CreatePrior(priorDef)
+	if err != nil {
+		return pred, err
+	}
+	prod := mult(likelihood.Function, prior.Function)
 
 	pred.Likelihood = likelihood.Function
 	pred.Prior = prior.Function
 	pred.Function = prod
+	pred.LogFunction = func(x float64) float64 {
+		return likelihood.LogFunction(x) + prior.LogFunction(x)
+	}
+	pred.min, pred.max = prior.min, prior.max
 
-  // handle point priors
+	// handle point priors
 	if prior.Name == "point" {
-		pred.Auc = likelihoodFunction(prior.point)
-    return pred
+		pred.isPoint = true
+		pred.point = prior.point
+		pred.LogAuc = likelihood.LogFunction(prior.point)
+		pred.Auc = math.Exp(pred.LogAuc)
+		return pred, nil
 	}
 
-  // handle binomial likelihoods
-	if likelihood.Name == "binomial" {
-		pred.Auc = Integrate(prod, 0, 1)
-    return pred
-  }
+	// integrate over the parameter values that both support
+	min, max := likelihood.Support()
+	pred.min, pred.max = math.Max(pred.min, min), math.Min(pred.max, max)
+	pred.breaks = breakpoints(likelihood, prior)
+
+	// handle conjugate models
+	if !opts.Numeric {
+		if logAuc, ok := conjugate(likelihoodDef, priorDef); ok {
+			pred.LogAuc = logAuc
+			pred.Auc = math.Exp(logAuc)
+			return pred, nil
+		}
+	}
 
-  // handle general case
-  pred.Auc = Integrate(prod, math.Inf(-1), math.Inf(1))
+	// integrate on the log scale so that the marginal likelihood can be
+	// found even when it underflows
+	auc, err := opts.Quadrature.IntegrateLog(pred.LogFunction, pred.min, pred.max, pred.breaks...)
+	pred.LogAuc = auc.LogValue
+	pred.Auc = math.Exp(auc.LogValue)
+	pred.AucError = pred.Auc * auc.RelError
+	if err != nil {
+		return pred, err
+	}
 
-	return pred
+	return pred, nil
 
 }
 
@@ -242,130 +553,228 @@ func BinomialLikelihood(successes float64, trials float64) func(x float64) float
 	}
 }
 
-// normal prior
+// poisson likelihood of a rate given a count over an exposure
 
-func NormalPrior(mean float64, sd float64, min float64, max float64) Prior {
+func PoissonLikelihood(count float64, exposure float64) func(x float64) float64 {
+	return func(x float64) float64 {
+		return Dpois(count, x*exposure)
+	}
+}
 
-	// If max and max are +/-Inf then set K to 1
-	// otherwise, integrate and normalize
-	if min == math.Inf(-1) && max == math.Inf(1) {
+// negative binomial likelihood of a rate given a count over an exposure
 
-		var prior Prior
-		prior.point = 0
-		prior.Function = func(x float64) float64 {
-			return Dnorm(x, mean, sd)
-		}
-		prior.Name = "normal"
-		return prior
-	} else if (min == 0.0 && max == math.Inf(1)) || (min == math.Inf(-1) && max == 0.0) {
-		k := 2.0
-		var prior Prior
-		prior.Function = func(x float64) float64 {
-			return (Dnorm(x, mean, sd) * inrange(x, min, max)) * k
-		}
-		prior.Name = "normal"
-		return prior
-	} else {
-		normal := func(x float64) float64 {
-			return Dnorm(x, mean, sd) * inrange(x, min, max)
-		}
-		auc := Integrate(normal, math.Inf(-1), math.Inf(1))
-		k := 1 / auc
-		var prior Prior
-		prior.Function = func(x float64) float64 {
-			return (Dnorm(x, mean, sd) * inrange(x, min, max)) * k
-		}
-		prior.Name = "normal"
-		return prior
+func NegativeBinomialLikelihood(count float64, size float64, exposure float64) func(x float64) float64 {
+	return func(x float64) float64 {
+		return Dnbinom(count, size, x*exposure)
 	}
 }
 
-// student t prior
+// correlation likelihood of rho given a sample correlation r of n pairs
 
-func StudentTPrior(mean float64, sd float64, df float64, min float64, max float64) Prior {
+func CorrelationLikelihood(r float64, n float64) func(x float64) float64 {
+	return func(x float64) float64 {
+		return Dcor(r, x, n)
+	}
+}
 
-	// If max and max are +/-Inf then set K to 1
-	// otherwise, integrate and normalize
-	if min == math.Inf(-1) && max == math.Inf(1) {
+// correlation likelihood with the fisher z approximation, where atanh(r)
+// is normal with mean atanh(rho) and sd 1 / sqrt(n - 3)
 
-		var prior Prior
-		prior.point = 0
-		prior.Function = func(x float64) float64 {
-			return Scaled_shifted_t(x, mean, sd, df)
-		}
-		prior.Name = "student_t"
-		return prior
-	} else if (min == 0.0 && max == math.Inf(1)) || (min == math.Inf(-1) && max == 0.0) {
-		k := 2.0
-		var prior Prior
-		prior.Function = func(x float64) float64 {
-			return (Scaled_shifted_t(x, mean, sd, df) * inrange(x, min, max)) * k
-		}
-		prior.Name = "student_t"
-		return prior
-	} else {
-		normal := func(x float64) float64 {
-			return Scaled_shifted_t(x, mean, sd, df) * inrange(x, min, max)
-		}
-		auc := Integrate(normal, math.Inf(-1), math.Inf(1))
-		k := 1 / auc
-		var prior Prior
-		prior.Function = func(x float64) float64 {
-			return (Scaled_shifted_t(x, mean, sd, df) * inrange(x, min, max)) * k
-		}
-		prior.Name = "student_t"
-		return prior
+func CorrelationZLikelihood(r float64, n float64) func(x float64) float64 {
+	return func(x float64) float64 {
+		return Dnorm(math.Atanh(r), math.Atanh(x), 1/math.Sqrt(n-3))
 	}
 }
 
-// cauchy prior
+// distribution collects the functions of a distribution family with its
+// parameters fixed
+type distribution struct {
+	logDensity func(x float64) float64
+	random     func(src rand.Source) float64
+	cdf        func(q float64, lowerTail bool, logP bool) float64
+	quantile   func(p float64, lowerTail bool, logP bool) float64
+}
 
-func CauchyPrior(location float64, scale float64, min float64, max float64) Prior {
+// logMass returns the log of the probability between min and max. It is
+// computed in the tail that the interval is in so that it doesn't lose
+// precision, or underflow, far from the bulk of the distribution.
+func logMass(cdf func(q float64, lowerTail bool, logP bool) float64, min float64, max float64) float64 {
+	if cdf(min, true, false) > 0.5 {
+		upper, lower := cdf(min, false, true), cdf(max, false, true)
+		return upper + math.Log1p(-math.Exp(lower-upper))
+	}
+	upper, lower := cdf(max, true, true), cdf(min, true, true)
+	return upper + math.Log1p(-math.Exp(lower-upper))
+}
 
-	// If max and max are +/-Inf then set K to 1
-	// otherwise, integrate and normalize
-	if min == math.Inf(-1) && max == math.Inf(1) {
+// truncate returns a prior with the density of dist truncated to
+// [min, max] and normalized by the probability between the bounds
+func truncate(name string, dist distribution, min float64, max float64) Prior {
 
-		var prior Prior
-		prior.point = 0
-		prior.Function = func(x float64) float64 {
-			return Dcauchy(x, location, scale)
-		}
-		prior.Name = "cauchy"
-		return prior
-	} else if (min == 0.0 && max == math.Inf(1)) || (min == math.Inf(-1) && max == 0.0) {
-		k := 2.0
-		var prior Prior
-		prior.Function = func(x float64) float64 {
-			return (Dcauchy(x, location, scale) * inrange(x, min, max)) * k
-		}
-		prior.Name = "cauchy"
-		return prior
+	logMass := logMass(dist.cdf, min, max)
+
+	var prior Prior
+	if math.IsInf(logMass, 0) || math.IsNaN(logMass) {
+		prior.err = &ModelError{Kind: "prior", Family: name, Param: "min/max", Err: ErrNoMass}
+	}
+	logFunction := func(x float64) float64 {
+		return dist.logDensity(x) + logInrange(x, min, max) - logMass
+	}
+	prior.LogFunction = logFunction
+	// the density is computed on the log scale so that it doesn't
+	// underflow when the bounds are far into a tail
+	prior.Function = func(x float64) float64 {
+		return math.Exp(logFunction(x))
+	}
+	if logMass == 0 {
+		prior.sampler = dist.random
 	} else {
-		cauchy := func(x float64) float64 {
-			return Dcauchy(x, location, scale) * inrange(x, min, max)
-		}
-		auc := Integrate(cauchy, math.Inf(-1), math.Inf(1))
-		k := 1 / auc
-		var prior Prior
-		prior.Function = func(x float64) float64 {
-			return (Dcauchy(x, location, scale) * inrange(x, min, max)) * k
+		prior.sampler = func(src rand.Source) float64 {
+			return Rtruncated(dist.cdf, dist.quantile, min, max, src)
 		}
-		prior.Name = "cauchy"
-		return prior
 	}
+	prior.Name = name
+	prior.min, prior.max, prior.bounded = min, max, true
+	prior.dist = &dist
+	return prior
+}
+
+// normalDistribution returns the functions of a normal distribution
+func normalDistribution(mean float64, sd float64) distribution {
+	return distribution{
+		logDensity: func(x float64) float64 { return LogDnorm(x, mean, sd) },
+		random:     func(src rand.Source) float64 { return Rnorm(mean, sd, src) },
+		cdf: func(q float64, lowerTail bool, logP bool) float64 {
+			return Pnorm(q, mean, sd, lowerTail, logP)
+		},
+		quantile: func(p float64, lowerTail bool, logP bool) float64 {
+			return Qnorm(p, mean, sd, lowerTail, logP)
+		},
+	}
+}
+
+// betaDistribution returns the functions of a beta distribution
+func betaDistribution(alpha float64, beta float64) distribution {
+	return distribution{
+		logDensity: func(x float64) float64 { return LogDbeta(x, alpha, beta) },
+		random:     func(src rand.Source) float64 { return Rbeta(alpha, beta, src) },
+		cdf: func(q float64, lowerTail bool, logP bool) float64 {
+			return Pbeta(q, alpha, beta, lowerTail, logP)
+		},
+		quantile: func(p float64, lowerTail bool, logP bool) float64 {
+			return Qbeta(p, alpha, beta, lowerTail, logP)
+		},
+	}
+}
+
+// gammaDistribution returns the functions of a gamma distribution
+func gammaDistribution(shape float64, rate float64) distribution {
+	return distribution{
+		logDensity: func(x float64) float64 { return LogDgamma(x, shape, rate) },
+		random:     func(src rand.Source) float64 { return Rgamma(shape, rate, src) },
+		cdf: func(q float64, lowerTail bool, logP bool) float64 {
+			return Pgamma(q, shape, rate, lowerTail, logP)
+		},
+		quantile: func(p float64, lowerTail bool, logP bool) float64 {
+			return Qgamma(p, shape, rate, lowerTail, logP)
+		},
+	}
+}
+
+// normal prior
+
+func NormalPrior(mean float64, sd float64, min float64, max float64) Prior {
+	prior := truncate("normal", normalDistribution(mean, sd), min, max)
+	prior.center, prior.scale = mean, sd
+	return prior
+}
+
+// student t prior
+
+func StudentTPrior(mean float64, sd float64, df float64, min float64, max float64) Prior {
+	prior := truncate("student_t", distribution{
+		logDensity: func(x float64) float64 { return LogScaled_shifted_t(x, mean, sd, df) },
+		random:     func(src rand.Source) float64 { return Rscaled_shifted_t(mean, sd, df, src) },
+		cdf: func(q float64, lowerTail bool, logP bool) float64 {
+			return Pscaled_shifted_t(q, mean, sd, df, lowerTail, logP)
+		},
+		quantile: func(p float64, lowerTail bool, logP bool) float64 {
+			return Qscaled_shifted_t(p, mean, sd, df, lowerTail, logP)
+		},
+	}, min, max)
+	prior.center, prior.scale = mean, sd
+	return prior
+}
+
+// cauchy prior
+
+func CauchyPrior(location float64, scale float64, min float64, max float64) Prior {
+	prior := truncate("cauchy", distribution{
+		logDensity: func(x float64) float64 { return LogDcauchy(x, location, scale) },
+		random:     func(src rand.Source) float64 { return Rcauchy(location, scale, src) },
+		cdf: func(q float64, lowerTail bool, logP bool) float64 {
+			return Pcauchy(q, location, scale, lowerTail, logP)
+		},
+		quantile: func(p float64, lowerTail bool, logP bool) float64 {
+			return Qcauchy(p, location, scale, lowerTail, logP)
+		},
+	}, min, max)
+	prior.center, prior.scale = location, scale
+	return prior
 }
 
 // beta prior
 
 func BetaPrior(alpha float64, beta float64, min float64, max float64) Prior {
+	prior := truncate("beta", betaDistribution(alpha, beta), min, max)
+	prior.center = alpha / (alpha + beta)
+	prior.scale = math.Sqrt(alpha*beta/(alpha+beta+1)) / (alpha + beta)
+	return prior
+}
 
-	var prior Prior
-	prior.point = 0
-	prior.Function = func(x float64) float64 {
-		return Dbeta(x, alpha, beta) * inrange(x, min, max)
-	}
-	prior.Name = "beta"
+// stretched beta prior, a beta distribution stretched to [-1, 1]. With
+// alpha = beta = 1 / kappa it is the default prior on a correlation of Ly
+// et al. (2016), which is uniform when kappa = 1.
+
+func StretchedBetaPrior(alpha float64, beta float64, min float64, max float64) Prior {
+	dist := betaDistribution(alpha, beta)
+	prior := truncate("stretched_beta", distribution{
+		logDensity: func(x float64) float64 { return dist.logDensity((x+1)/2) - math.Ln2 },
+		random:     func(src rand.Source) float64 { return 2*dist.random(src) - 1 },
+		cdf: func(q float64, lowerTail bool, logP bool) float64 {
+			return dist.cdf((q+1)/2, lowerTail, logP)
+		},
+		quantile: func(p float64, lowerTail bool, logP bool) float64 {
+			return 2*dist.quantile(p, lowerTail, logP) - 1
+		},
+	}, min, max)
+	prior.center = 2*alpha/(alpha+beta) - 1
+	prior.scale = 2 * math.Sqrt(alpha*beta/(alpha+beta+1)) / (alpha + beta)
+	return prior
+}
+
+// gamma prior
+
+func GammaPrior(shape float64, rate float64, min float64, max float64) Prior {
+	prior := truncate("gamma", gammaDistribution(shape, rate), min, max)
+	prior.center, prior.scale = shape/rate, math.Sqrt(shape)/rate
+	return prior
+}
+
+// log-normal prior
+
+func LogNormalPrior(meanlog float64, sdlog float64, min float64, max float64) Prior {
+	prior := truncate("lognormal", distribution{
+		logDensity: func(x float64) float64 { return LogDlnorm(x, meanlog, sdlog) },
+		random:     func(src rand.Source) float64 { return Rlnorm(meanlog, sdlog, src) },
+		cdf: func(q float64, lowerTail bool, logP bool) float64 {
+			return Plnorm(q, meanlog, sdlog, lowerTail, logP)
+		},
+		quantile: func(p float64, lowerTail bool, logP bool) float64 {
+			return Qlnorm(p, meanlog, sdlog, lowerTail, logP)
+		},
+	}, min, max)
+	prior.center, prior.scale = math.Exp(meanlog), math.Exp(meanlog)*sdlog
 	return prior
 }
 
@@ -380,20 +789,35 @@ func PointPrior(point float64) Prior {
 		}
 		return 0
 	}
+	prior.LogFunction = func(x float64) float64 {
+		if x == point {
+			return 0
+		}
+		return math.Inf(-1)
+	}
+	prior.sampler = func(src rand.Source) float64 {
+		return point
+	}
 	prior.Name = "point"
 	prior.point = point
+	prior.min, prior.max, prior.bounded = point, point, true
+	prior.center = point
 	return prior
 }
 
 // uniform prior
 
 func UniformPrior(alpha float64, beta float64) Prior {
-
-	var prior Prior
-	prior.point = 0
-	prior.Function = func(x float64) float64 {
-		return Dunif(x, alpha, beta)
-	}
-	prior.Name = "uniform"
+	prior := truncate("uniform", distribution{
+		logDensity: func(x float64) float64 { return LogDunif(x, alpha, beta) },
+		random:     func(src rand.Source) float64 { return Runif(alpha, beta, src) },
+		cdf: func(q float64, lowerTail bool, logP bool) float64 {
+			return Punif(q, alpha, beta, lowerTail, logP)
+		},
+		quantile: func(p float64, lowerTail bool, logP bool) float64 {
+			return Qunif(p, alpha, beta, lowerTail, logP)
+		},
+	}, alpha, beta)
+	prior.center, prior.scale = (alpha+beta)/2, (beta-alpha)/2
 	return prior
 }
@@ -1,10 +1,14 @@
 package bayesfactor
 
 import (
+	"errors"
 	"math"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"golang.org/x/exp/rand"
+
+	"pkg/distributions"
 )
 
 func compare(t *testing.T, got, want float64) {
@@ -104,7 +108,7 @@ func TestBayesfactor(t *testing.T) {
 	bf, _ = Bayesfactor(likelihood, altprior, nullprior)
 	got = bf
 	want = 2.327971
-	// compare(t, got, want)
+	compare(t, got, want)
 
 	likelihood.Name = "student_t"
 	likelihood.Params = []float64{5.47, 32.2, 119}
@@ -152,6 +156,395 @@ func TestBayesfactor(t *testing.T) {
 
 }
 
+func TestValidation(t *testing.T) {
+
+	inf := math.Inf(1)
+	point := PriorDefinition{Name: "point", Params: []float64{0}}
+	cauchy := PriorDefinition{Name: "cauchy", Params: []float64{0, 1, -inf, inf}}
+	normal := LikelihoodDefinition{Name: "normal", Params: []float64{0, 1}}
+
+	cases := []struct {
+		name       string
+		likelihood LikelihoodDefinition
+		altprior   PriorDefinition
+		want       error
+	}{
+		{"unknown likelihood", LikelihoodDefinition{Name: "nromal", Params: []float64{0, 1}}, cauchy, ErrUnknownFamily},
+		{"unknown prior", normal, PriorDefinition{Name: "cuachy", Params: []float64{0, 1}}, ErrUnknownFamily},
+		{"missing params", LikelihoodDefinition{Name: "normal", Params: []float64{0}}, cauchy, ErrParamCount},
+		{"prior missing params", normal, PriorDefinition{Name: "cauchy", Params: []float64{0, 1, 0}}, ErrParamCount},
+		{"zero sd", LikelihoodDefinition{Name: "normal", Params: []float64{0, 0}}, cauchy, ErrNonPositiveSD},
+		{"negative scale", normal, PriorDefinition{Name: "cauchy", Params: []float64{0, -1, -inf, inf}}, ErrNonPositiveSD},
+		{"zero df", LikelihoodDefinition{Name: "noncentral_t", Params: []float64{2, 0}}, cauchy, ErrNonPositiveDF},
+		{"min >= max", normal, PriorDefinition{Name: "normal", Params: []float64{0, 1, 1, 0}}, ErrInvalidRange},
+		{"uniform range", normal, PriorDefinition{Name: "uniform", Params: []float64{2, 2}}, ErrInvalidRange},
+		{"successes > trials", LikelihoodDefinition{Name: "binomial", Params: []float64{12, 10}}, PriorDefinition{Name: "beta", Params: []float64{1, 1}}, ErrInvalidCount},
+		{"sample size", LikelihoodDefinition{Name: "noncentral_d", Params: []float64{0.2, 1}}, cauchy, ErrSampleSize},
+		{"beta shape", LikelihoodDefinition{Name: "binomial", Params: []float64{2, 10}}, PriorDefinition{Name: "beta", Params: []float64{0, 1}}, ErrInvalidShape},
+		{"nan mean", LikelihoodDefinition{Name: "normal", Params: []float64{math.NaN(), 1}}, cauchy, ErrNotFinite},
+		{"infinite n", LikelihoodDefinition{Name: "noncentral_d", Params: []float64{0.5, inf}}, cauchy, ErrNotFinite},
+		{"infinite n2", LikelihoodDefinition{Name: "noncentral_d2", Params: []float64{0.5, 20, inf}}, cauchy, ErrNotFinite},
+		{"infinite trials", LikelihoodDefinition{Name: "binomial", Params: []float64{3, inf}}, PriorDefinition{Name: "beta", Params: []float64{1, 1}}, ErrNotFinite},
+		{"infinite df", LikelihoodDefinition{Name: "noncentral_t", Params: []float64{2, inf}}, cauchy, ErrNotFinite},
+		{"infinite prior df", normal, PriorDefinition{Name: "student_t", Params: []float64{0, 1, inf, -inf, inf}}, ErrNotFinite},
+		{"fractional count", LikelihoodDefinition{Name: "poisson", Params: []float64{2.5, 1}}, PriorDefinition{Name: "gamma", Params: []float64{1, 1}}, ErrNotCount},
+		{"negative count", LikelihoodDefinition{Name: "negative_binomial", Params: []float64{-1, 2, 1}}, PriorDefinition{Name: "gamma", Params: []float64{1, 1}}, ErrNotCount},
+		{"zero exposure", LikelihoodDefinition{Name: "poisson", Params: []float64{2, 0}}, PriorDefinition{Name: "gamma", Params: []float64{1, 1}}, ErrNonPositive},
+		{"gamma rate", LikelihoodDefinition{Name: "poisson", Params: []float64{2, 1}}, PriorDefinition{Name: "gamma", Params: []float64{1, 0}}, ErrNonPositive},
+		{"lognormal sd", LikelihoodDefinition{Name: "poisson", Params: []float64{2, 1}}, PriorDefinition{Name: "lognormal", Params: []float64{0, -1}}, ErrNonPositiveSD},
+	}
+
+	for _, c := range cases {
+		bf, err := Bayesfactor(c.likelihood, c.altprior, point)
+		if !errors.Is(err, c.want) {
+			t.Fatalf("%s: got error %v, wanted %v", c.name, err, c.want)
+		}
+		var modelErr *ModelError
+		if !errors.As(err, &modelErr) {
+			t.Fatalf("%s: got error of type %T, wanted *ModelError", c.name, err)
+		}
+		if !math.IsNaN(bf) {
+			t.Fatalf("%s: got bf %v, wanted NaN", c.name, bf)
+		}
+	}
+
+	// valid definitions must not return an error
+	if _, err := Bayesfactor(normal, cauchy, point); err != nil {
+		t.Fatalf("got error %v, wanted nil", err)
+	}
+}
+
+func TestPpWithOptions(t *testing.T) {
+
+	inf := math.Inf(1)
+
+	// a very sharp likelihood: the marginal likelihood is
+	// dnorm(0.3, 0, sqrt(1 + 1e-8))
+	likelihood := LikelihoodDefinition{Name: "normal", Params: []float64{0.3, 1e-4}}
+	prior := PriorDefinition{Name: "normal", Params: []float64{0, 1, -inf, inf}}
+	pred, err := Pp(likelihood, prior)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	compare(t, pred.Auc, 0.3813878)
+	if pred.AucError > 1e-8*pred.Auc {
+		t.Fatalf("got error estimate %v for %v", pred.AucError, pred.Auc)
+	}
+
+	// larger samples
+	likelihood = LikelihoodDefinition{Name: "noncentral_d", Params: []float64{0.1, 300}}
+	prior = PriorDefinition{Name: "cauchy", Params: []float64{0, 0.707, -inf, inf}}
+	pred, err = Pp(likelihood, prior)
+	if err != nil || pred.AucError > 1e-8*pred.Auc {
+		t.Fatalf("got %v with error estimate %v and error %v", pred.Auc, pred.AucError, err)
+	}
+
+	// the tolerance can't be met with a single interval
+	opts := DefaultOptions
+	opts.Quadrature = distributions.Quadrature{RelTol: 1e-14, MaxIntervals: 1}
+	if _, err := PpWithOptions(likelihood, prior, opts); !errors.Is(err, distributions.ErrTolerance) {
+		t.Fatalf("got error %v, wanted %v", err, distributions.ErrTolerance)
+	}
+}
+
+func TestLogBayesfactor(t *testing.T) {
+
+	inf := math.Inf(1)
+
+	// both marginal likelihoods underflow: the log BF is
+	// log(dnorm(40, 0, sqrt(101))) - log(dnorm(40, 0, 1))
+	likelihood := LikelihoodDefinition{Name: "normal", Params: []float64{40, 1}}
+	altprior := PriorDefinition{Name: "normal", Params: []float64{0, 10, -inf, inf}}
+	nullprior := PriorDefinition{Name: "point", Params: []float64{0}}
+
+	for _, opts := range []Options{DefaultOptions, {Quadrature: distributions.DefaultQuadrature, Numeric: true}} {
+		got, err := LogBayesfactorWithOptions(likelihood, altprior, nullprior, opts)
+		if err != nil {
+			t.Fatalf("got error %v", err)
+		}
+		compare(t, got, 789.7716)
+	}
+
+	pred, err := Pp(likelihood, nullprior)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	compare(t, pred.LogAuc, -800.9189)
+
+	// the BF itself overflows
+	bf, err := Bayesfactor(likelihood, altprior, nullprior)
+	if err != nil || !math.IsInf(bf, 1) {
+		t.Fatalf("got %v with error %v, wanted +Inf", bf, err)
+	}
+
+	// large samples with a non-conjugate prior
+	likelihood = LikelihoodDefinition{Name: "noncentral_d", Params: []float64{0.5, 5000}}
+	altprior = PriorDefinition{Name: "cauchy", Params: []float64{0, 0.707, -inf, inf}}
+	got, err := LogBayesfactor(likelihood, altprior, nullprior)
+	if err != nil || math.IsInf(got, 0) || math.IsNaN(got) || got < 500 {
+		t.Fatalf("got %v with error %v, wanted a large finite log BF", got, err)
+	}
+
+	// the comparison keeps both marginal likelihoods
+	comparison, err := Compare(likelihood, altprior, nullprior)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	if comparison.LogBF10 != got || comparison.Alt.LogAuc-comparison.Null.LogAuc != got {
+		t.Fatalf("got %+v, wanted a log BF of %v", comparison, got)
+	}
+	if comparison.BF10() != math.Exp(got) || comparison.BF01() != math.Exp(-got) {
+		t.Fatalf("got BF10 %v and BF01 %v", comparison.BF10(), comparison.BF01())
+	}
+
+	// values that can't be represented in JSON are nil
+	if *Finite(got) != got || Finite(inf) != nil || Finite(-inf) != nil || Finite(math.NaN()) != nil {
+		t.Fatalf("got the wrong pointers from Finite")
+	}
+}
+
+func TestTruncatedPrior(t *testing.T) {
+
+	inf := math.Inf(1)
+
+	// an off-centre half-normal is not twice the normal density:
+	// dnorm(1, 0.5, 1) / pnorm(0.5)
+	prior := NormalPrior(0.5, 1, 0, inf)
+	compare(t, prior.Function(1), 0.5091604)
+	compare(t, math.Exp(prior.LogFunction(1)), 0.5091604)
+
+	// dcauchy(0, 0.3, 1) / (pcauchy(2, 0.3, 1) - pcauchy(-1, 0.3, 1))
+	prior = CauchyPrior(0.3, 1, -1, 2)
+	compare(t, prior.Function(0), 0.4694729)
+
+	// every truncated prior integrates to 1, including one far in the tail
+	for _, prior := range []Prior{
+		NormalPrior(0.5, 1, 0, inf),
+		NormalPrior(1, 2, -inf, 0),
+		NormalPrior(0, 1, 40, inf),
+		CauchyPrior(0.3, 1, -1, 2),
+		CauchyPrior(-2, 0.707, 0, inf),
+		StudentTPrior(-1, 1, 3, 0, inf),
+		StudentTPrior(2, 0.5, 10, 1, 3),
+		BetaPrior(2, 3, 0.5, 1),
+	} {
+		if prior.err != nil {
+			t.Fatalf("got error %v", prior.err)
+		}
+		auc, err := distributions.IntegrateAdaptive(prior.Function, prior.min, prior.max, prior.center)
+		if err != nil {
+			t.Fatalf("got error %v", err)
+		}
+		compare(t, auc.Value, 1)
+	}
+
+	// the numeric marginal likelihood agrees with the closed form, which
+	// uses the exact mass of the truncated prior
+	likelihood := LikelihoodDefinition{Name: "normal", Params: []float64{0.8, 0.4}}
+	altprior := PriorDefinition{Name: "normal", Params: []float64{0.5, 1, 0, inf}}
+	nullprior := PriorDefinition{Name: "point", Params: []float64{0}}
+	closed, err := BayesfactorWithOptions(likelihood, altprior, nullprior, DefaultOptions)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	numeric, err := BayesfactorWithOptions(likelihood, altprior, nullprior, Options{Quadrature: distributions.DefaultQuadrature, Numeric: true})
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	compare(t, numeric, closed)
+
+	// there is no mass outside of the support
+	if prior := BetaPrior(2, 3, 1.5, 2); !errors.Is(prior.err, ErrNoMass) {
+		t.Fatalf("got error %v, wanted %v", prior.err, ErrNoMass)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+
+	inf := math.Inf(1)
+
+	// a prior defined only by its density is renormalized numerically: the
+	// laplace density on [0, inf) is the exponential density
+	laplace := Prior{
+		Name:     "laplace",
+		Function: func(x float64) float64 { return math.Exp(-math.Abs(x)) / 2 },
+		scale:    1,
+	}
+	prior := Truncate(laplace, 0, inf)
+	if prior.err != nil {
+		t.Fatalf("got error %v", prior.err)
+	}
+	compare(t, prior.Function(1), math.Exp(-1))
+	if min, max := prior.Support(); min != 0 || max != inf {
+		t.Fatalf("got support [%v, %v], wanted [0, %v]", min, max, inf)
+	}
+	if got := prior.Function(-1); got != 0 {
+		t.Fatalf("got %v, wanted 0", got)
+	}
+
+	// the bounds of every bounded prior are optional
+	cases := []struct {
+		prior PriorDefinition
+		x     float64
+		want  float64
+	}{
+		// dbeta(0.3, 2, 3) / (pbeta(0.6, 2, 3) - pbeta(0.2, 2, 3))
+		{PriorDefinition{Name: "beta", Params: []float64{2, 3, 0.2, 0.6}}, 0.3, 2.75625},
+		{PriorDefinition{Name: "beta", Params: []float64{2, 3, -inf, inf}}, 0.3, 1.764},
+		{PriorDefinition{Name: "uniform", Params: []float64{-1, 3, 0, inf}}, 1, 1.0 / 3},
+		{PriorDefinition{Name: "uniform", Params: []float64{-1, 3, 0, inf}}, -0.5, 0},
+		{PriorDefinition{Name: "normal", Params: []float64{0, 1}}, 0, 1 / math.Sqrt(2*math.Pi)},
+		{PriorDefinition{Name: "cauchy", Params: []float64{0, 1}}, 0, 1 / math.Pi},
+		{PriorDefinition{Name: "student_t", Params: []float64{0, 1, 1}}, 0, 1 / math.Pi},
+	}
+	for _, c := range cases {
+		prior, err := CreatePrior(c.prior)
+		if err != nil {
+			t.Fatalf("%v: got error %v", c.prior, err)
+		}
+		if got := prior.Function(c.x); c.want == 0 && got != 0 {
+			t.Fatalf("%v: got %v, wanted 0", c.prior, got)
+		} else if c.want != 0 {
+			compare(t, got, c.want)
+		}
+	}
+
+	// the numeric marginal likelihoods agree with the closed forms
+	models := []struct {
+		likelihood LikelihoodDefinition
+		prior      PriorDefinition
+	}{
+		{LikelihoodDefinition{Name: "binomial", Params: []float64{8, 11}}, PriorDefinition{Name: "beta", Params: []float64{2, 3, 0.2, 0.6}}},
+		{LikelihoodDefinition{Name: "normal", Params: []float64{0.8, 0.4}}, PriorDefinition{Name: "uniform", Params: []float64{-1, 3, 0, inf}}},
+	}
+	for _, m := range models {
+		null := PriorDefinition{Name: "point", Params: []float64{0.5}}
+		closed, err := BayesfactorWithOptions(m.likelihood, m.prior, null, DefaultOptions)
+		if err != nil {
+			t.Fatalf("got error %v", err)
+		}
+		numeric, err := BayesfactorWithOptions(m.likelihood, m.prior, null, Options{Quadrature: distributions.DefaultQuadrature, Numeric: true})
+		if err != nil {
+			t.Fatalf("got error %v", err)
+		}
+		compare(t, numeric, closed)
+	}
+
+	// a point prior outside of the bounds has no mass
+	if prior := Truncate(PointPrior(2), 0, 1); !errors.Is(prior.err, ErrNoMass) {
+		t.Fatalf("got error %v, wanted %v", prior.err, ErrNoMass)
+	}
+
+	// three parameters are neither the bare nor the truncated prior
+	_, err := CreatePrior(PriorDefinition{Name: "beta", Params: []float64{2, 3, 0.2}})
+	if !errors.Is(err, ErrParamCount) {
+		t.Fatalf("got error %v, wanted %v", err, ErrParamCount)
+	}
+}
+
+func TestTypicalRange(t *testing.T) {
+
+	inf := math.Inf(1)
+
+	likelihood, _ := CreateLikelihood(LikelihoodDefinition{Name: "normal", Params: []float64{5.5, 32.35}})
+	if min, max := likelihood.Support(); min != -inf || max != inf {
+		t.Fatalf("got support [%v, %v], wanted [%v, %v]", min, max, -inf, inf)
+	}
+	min, max := likelihood.TypicalRange()
+	compare(t, min, 5.5-4*32.35)
+	compare(t, max, 5.5+4*32.35)
+
+	// the normalized binomial likelihood is a beta(9, 4) density, whose
+	// typical range is cut off at 1
+	likelihood, _ = CreateLikelihood(LikelihoodDefinition{Name: "binomial", Params: []float64{8, 11}})
+	if min, max := likelihood.Support(); min != 0 || max != 1 {
+		t.Fatalf("got support [%v, %v], wanted [0, 1]", min, max)
+	}
+	min, max = likelihood.TypicalRange()
+	compare(t, min, 0.1905837)
+	compare(t, max, 1)
+
+	// rates are positive, and the normalized poisson likelihood of no events
+	// is an exponential density whose 95% quantile is about 0.3
+	likelihood, _ = CreateLikelihood(LikelihoodDefinition{Name: "poisson", Params: []float64{0, 10}})
+	if min, max := likelihood.Support(); min != 0 || max != inf {
+		t.Fatalf("got support [%v, %v], wanted [0, %v]", min, max, inf)
+	}
+	min, max = likelihood.TypicalRange()
+	if !(min == 0 && max > 0.3 && max < 1) {
+		t.Fatalf("got typical range [%v, %v]", min, max)
+	}
+
+	// a central interval of a cauchy prior would be very wide
+	cauchy := CauchyPrior(0, 1, -inf, inf)
+	min, max = cauchy.TypicalRange()
+	compare(t, min, -5.930409)
+	compare(t, max, 5.930409)
+
+	// the typical range of a truncated prior is within its bounds, even
+	// far into a tail
+	for _, prior := range []Prior{
+		NormalPrior(0, 1, 40, inf),
+		BetaPrior(2, 3, 0.5, 1),
+		UniformPrior(-1, 3),
+		Truncate(cauchy, -inf, -2),
+		GammaPrior(2, 1, 0, inf),
+		LogNormalPrior(0, 1, 2, inf),
+	} {
+		lower, upper := prior.Support()
+		min, max := prior.TypicalRange()
+		if !(lower <= min && min < max && max <= upper) || math.IsInf(min, 0) || math.IsInf(max, 0) {
+			t.Fatalf("got typical range [%v, %v] for support [%v, %v]", min, max, lower, upper)
+		}
+	}
+
+	if min, max := PointPrior(0.5).TypicalRange(); min != 0.5 || max != 0.5 {
+		t.Fatalf("got [%v, %v], wanted [0.5, 0.5]", min, max)
+	}
+}
+
+func TestPriorRand(t *testing.T) {
+
+	inf := math.Inf(1)
+	src := rand.NewSource(1)
+
+	cases := []struct {
+		prior    Prior
+		min, max float64
+		mean     float64
+	}{
+		{NormalPrior(0, 1, 0, inf), 0, inf, math.Sqrt(2 / math.Pi)},
+		{NormalPrior(1, 2, -1, 2), -1, 2, 0.5867376},
+		{CauchyPrior(0, 0.707, -inf, inf), -inf, inf, math.NaN()},
+		{CauchyPrior(0, 1, -1, 1), -1, 1, 0},
+		{StudentTPrior(0, 1, 3, -inf, 0), -inf, 0, -2 * math.Sqrt(3) / math.Pi},
+		{BetaPrior(2, 3, 0, 1), 0, 1, 0.4},
+		{UniformPrior(-1, 3), -1, 3, 1},
+		{GammaPrior(2, 4, 0, inf), 0, inf, 0.5},
+		{LogNormalPrior(0, 0.5, 0, inf), 0, inf, math.Exp(0.125)},
+		{PointPrior(0.5), 0.5, 0.5, 0.5},
+	}
+	for _, c := range cases {
+		var sum float64
+		const n = 5000
+		for i := 0; i < n; i++ {
+			x := c.prior.Rand(src)
+			if !(x >= c.min && x <= c.max) {
+				t.Fatalf("%v prior: got %v outside of [%v, %v]", c.prior.Name, x, c.min, c.max)
+			}
+			sum += x
+		}
+		if !math.IsNaN(c.mean) && math.Abs(sum/n-c.mean) > 0.05 {
+			t.Fatalf("%v prior: got mean %v, wanted %v", c.prior.Name, sum/n, c.mean)
+		}
+	}
+
+	var prior Prior
+	if got := prior.Rand(src); !math.IsNaN(got) {
+		t.Fatalf("got %v, wanted NaN", got)
+	}
+}
+
 // func BenchmarkPlots(b *testing.B) {
 //
 // 	mean := 0.0
@@ -242,3 +635,80 @@ func TestBayesfactor(t *testing.T) {
 // 	}
 // }
 
+func TestCountModels(t *testing.T) {
+
+	inf := math.Inf(1)
+
+	// overdispersed counts with a log-normal prior on the rate are only
+	// integrated over positive rates
+	likelihood := LikelihoodDefinition{Name: "negative_binomial", Params: []float64{7, 2, 3}}
+	prior := PriorDefinition{Name: "lognormal", Params: []float64{0, 1}}
+	pred, err := Pp(likelihood, prior)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	want, err := distributions.IntegrateAdaptive(func(x float64) float64 {
+		return distributions.Dnbinom(7, 2, 3*x) * distributions.Dlnorm(x, 0, 1)
+	}, 0, inf, 1)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	compare(t, pred.Auc, want.Value)
+
+	bf, err := Bayesfactor(likelihood, prior, PriorDefinition{Name: "point", Params: []float64{1}})
+	if err != nil || math.IsNaN(bf) || math.IsInf(bf, 0) || !(bf > 0) {
+		t.Fatalf("got bf %v and error %v", bf, err)
+	}
+
+	// with a very large size the negative binomial is a poisson
+	nbinom, _ := Pp(LikelihoodDefinition{Name: "negative_binomial", Params: []float64{7, 1e8, 3}}, prior)
+	pois, _ := Pp(LikelihoodDefinition{Name: "poisson", Params: []float64{7, 3}}, prior)
+	compare(t, nbinom.Auc, pois.Auc)
+}
+
+func TestCorrelation(t *testing.T) {
+
+	point := PriorDefinition{Name: "point", Params: []float64{0}}
+	uniform := PriorDefinition{Name: "stretched_beta", Params: []float64{1, 1}}
+
+	// reference values are from Hotelling's integral form of the density of
+	// r, integrated over rho on a grid
+	cases := []struct {
+		likelihood string
+		prior      PriorDefinition
+		want       float64
+	}{
+		{"correlation", uniform, 1.5554512},
+		{"correlation", PriorDefinition{Name: "stretched_beta", Params: []float64{1, 1, 0, 1}}, 3.0518846},
+		{"correlation", PriorDefinition{Name: "stretched_beta", Params: []float64{2, 2, -1, 0}}, 0.0880775},
+		{"correlation_z", uniform, 1.5565345},
+		{"correlation_z", PriorDefinition{Name: "stretched_beta", Params: []float64{1, 1, 0, 1}}, 3.0545836},
+	}
+	for _, c := range cases {
+		likelihood := LikelihoodDefinition{Name: c.likelihood, Params: []float64{0.3, 50}}
+		bf, err := Bayesfactor(likelihood, c.prior, point)
+		if err != nil {
+			t.Fatalf("%v: got error %v", c.likelihood, err)
+		}
+		compare(t, bf, c.want)
+	}
+
+	// the stretched beta prior is a beta prior on (rho + 1) / 2
+	prior, _ := CreatePrior(PriorDefinition{Name: "stretched_beta", Params: []float64{2, 3}})
+	compare(t, prior.Function(0.2), distributions.Dbeta(0.6, 2, 3)/2)
+	if min, max := prior.Support(); min != -1 || max != 1 {
+		t.Fatalf("got support [%v, %v], wanted [-1, 1]", min, max)
+	}
+
+	likelihood, _ := CreateLikelihood(LikelihoodDefinition{Name: "correlation", Params: []float64{0.95, 20}})
+	if min, max := likelihood.TypicalRange(); !(min > 0 && max <= 1) {
+		t.Fatalf("got typical range [%v, %v]", min, max)
+	}
+
+	for _, params := range [][]float64{{1, 50}, {-1.2, 50}, {0.3, 3}} {
+		_, err := CreateLikelihood(LikelihoodDefinition{Name: "correlation", Params: params})
+		if !errors.Is(err, ErrCorrelation) && !errors.Is(err, ErrSampleSize) {
+			t.Fatalf("%v: got error %v", params, err)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package bayesfactor
+
+import (
+	"math"
+)
+
+// conjugate returns the log of the marginal likelihood in closed form for
+// the conjugate (and similar) pairs of likelihood and prior:
+//
+//   - normal likelihood with a (possibly truncated) normal prior
+//   - normal likelihood with a (possibly truncated) uniform prior
+//   - binomial likelihood with a (possibly truncated) beta prior
+//   - poisson likelihood with a (possibly truncated) gamma prior
+//
+// ok is false for every other combination. The definitions must already
+// have been validated.
+func conjugate(likelihood LikelihoodDefinition, prior PriorDefinition) (logAuc float64, ok bool) {
+
+	switch {
+	case likelihood.Name == "normal" && prior.Name == "normal":
+		m, s := likelihood.Params[0], likelihood.Params[1]
+		mu, tau := prior.Params[0], prior.Params[1]
+		min, max := prior.Params[2], prior.Params[3]
+
+		// the product is the marginal density of the observation times
+		// the (untruncated) posterior, so the truncation only changes the
+		// mass of the posterior and the prior within the bounds
+		v := s*s + tau*tau
+		postMean := (m*tau*tau + mu*s*s) / v
+		postSD := s * tau / math.Sqrt(v)
+		logMarginal := -(m-mu)*(m-mu)/(2*v) - math.Log(2*math.Pi*v)/2
+		logPostMass := logMass(normalDistribution(postMean, postSD).cdf, min, max)
+		logPriorMass := logMass(normalDistribution(mu, tau).cdf, min, max)
+		return logMarginal + logPostMass - logPriorMass, true
+
+	case likelihood.Name == "normal" && prior.Name == "uniform":
+		m, s := likelihood.Params[0], likelihood.Params[1]
+		min, max := bounds(prior, prior.Params[0], prior.Params[1])
+		return logMass(normalDistribution(m, s).cdf, min, max) - math.Log(max-min), true
+
+	case likelihood.Name == "binomial" && prior.Name == "beta":
+		k, n := likelihood.Params[0], likelihood.Params[1]
+		alpha, beta := prior.Params[0], prior.Params[1]
+		logMarginal := lchoose(n, k) + lbeta(k+alpha, n-k+beta) - lbeta(alpha, beta)
+		min, max := bounds(prior, 0, 1)
+		if min == 0 && max == 1 {
+			return logMarginal, true
+		}
+		logPostMass := logMass(betaDistribution(k+alpha, n-k+beta).cdf, min, max)
+		logPriorMass := logMass(betaDistribution(alpha, beta).cdf, min, max)
+		return logMarginal + logPostMass - logPriorMass, true
+
+	case likelihood.Name == "poisson" && prior.Name == "gamma":
+		k, t := likelihood.Params[0], likelihood.Params[1]
+		shape, rate := prior.Params[0], prior.Params[1]
+		lgk, _ := math.Lgamma(k + 1)
+		lga, _ := math.Lgamma(shape)
+		lgka, _ := math.Lgamma(k + shape)
+		logMarginal := k*math.Log(t) + shape*math.Log(rate) + lgka - lgk - lga - (k+shape)*math.Log(rate+t)
+		min, max := bounds(prior, 0, math.Inf(1))
+		if min == 0 && math.IsInf(max, 1) {
+			return logMarginal, true
+		}
+		logPostMass := logMass(gammaDistribution(k+shape, rate+t).cdf, min, max)
+		logPriorMass := logMass(gammaDistribution(shape, rate).cdf, min, max)
+		return logMarginal + logPostMass - logPriorMass, true
+	}
+
+	return math.NaN(), false
+}
+
+// bounds returns the support of a prior with optional bounds, [lower, upper],
+// restricted to its optional min and max parameters
+func bounds(prior PriorDefinition, lower float64, upper float64) (float64, float64) {
+	if len(prior.Params) == 4 {
+		lower = math.Max(lower, prior.Params[2])
+		upper = math.Min(upper, prior.Params[3])
+	}
+	return lower, upper
+}
+
+func lbeta(a float64, b float64) float64 {
+	la, _ := math.Lgamma(a)
+	lb, _ := math.Lgamma(b)
+	lab, _ := math.Lgamma(a + b)
+	return la + lb - lab
+}
+
+func lchoose(n float64, k float64) float64 {
+	return -math.Log(n+1) - lbeta(n-k+1, k+1)
+}
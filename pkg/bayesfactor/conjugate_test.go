@@ -0,0 +1,75 @@
+package bayesfactor
+
+import (
+	"math"
+	"testing"
+)
+
+func TestConjugate(t *testing.T) {
+
+	inf := math.Inf(1)
+	normal := func(mean, sd float64) LikelihoodDefinition {
+		return LikelihoodDefinition{Name: "normal", Params: []float64{mean, sd}}
+	}
+	binomial := func(successes, trials float64) LikelihoodDefinition {
+		return LikelihoodDefinition{Name: "binomial", Params: []float64{successes, trials}}
+	}
+	poisson := func(count, exposure float64) LikelihoodDefinition {
+		return LikelihoodDefinition{Name: "poisson", Params: []float64{count, exposure}}
+	}
+
+	cases := []struct {
+		name       string
+		likelihood LikelihoodDefinition
+		prior      PriorDefinition
+	}{
+		{"normal", normal(5, 10), PriorDefinition{Name: "normal", Params: []float64{0, 10, -inf, inf}}},
+		{"half normal", normal(5.5, 32.35), PriorDefinition{Name: "normal", Params: []float64{0, 13.3, 0, inf}}},
+		{"negative half normal", normal(-0.5, 1), PriorDefinition{Name: "normal", Params: []float64{0, 1, -inf, 0}}},
+		{"truncated normal", normal(0.3, 0.5), PriorDefinition{Name: "normal", Params: []float64{0.2, 1, -1, 2}}},
+		{"distant truncated normal", normal(4, 0.5), PriorDefinition{Name: "normal", Params: []float64{0, 1, 3, inf}}},
+		{"uniform", normal(0.3, 0.2), PriorDefinition{Name: "uniform", Params: []float64{-1, 1}}},
+		{"uniform tail", normal(5, 1), PriorDefinition{Name: "uniform", Params: []float64{0, 2}}},
+		{"beta", binomial(8, 11), PriorDefinition{Name: "beta", Params: []float64{2.5, 1}}},
+		{"uniform beta", binomial(2, 10), PriorDefinition{Name: "beta", Params: []float64{1, 1}}},
+		{"extreme beta", binomial(0, 50), PriorDefinition{Name: "beta", Params: []float64{0.5, 3}}},
+		{"gamma", poisson(3, 2), PriorDefinition{Name: "gamma", Params: []float64{2, 1}}},
+		{"truncated gamma", poisson(12, 4.5), PriorDefinition{Name: "gamma", Params: []float64{0.5, 0.1, 1, 5}}},
+		{"zero count", poisson(0, 10), PriorDefinition{Name: "gamma", Params: []float64{1, 1, 0, inf}}},
+	}
+
+	numeric := DefaultOptions
+	numeric.Numeric = true
+
+	for _, c := range cases {
+		analytic, err := Pp(c.likelihood, c.prior)
+		if err != nil {
+			t.Fatalf("%s: got error %v", c.name, err)
+		}
+		if analytic.AucError != 0 {
+			t.Fatalf("%s: closed form wasn't used", c.name)
+		}
+		integrated, err := PpWithOptions(c.likelihood, c.prior, numeric)
+		if err != nil {
+			t.Fatalf("%s: got error %v", c.name, err)
+		}
+		if math.Abs(analytic.Auc-integrated.Auc) > 1e-6*analytic.Auc {
+			t.Fatalf("%s: got %v analytically and %v numerically", c.name, analytic.Auc, integrated.Auc)
+		}
+	}
+
+	// models without a closed form are integrated
+	likelihood := LikelihoodDefinition{Name: "student_t", Params: []float64{5.47, 32.2, 119}}
+	pred, _ := Pp(likelihood, PriorDefinition{Name: "normal", Params: []float64{0, 10, -inf, inf}})
+	if pred.AucError == 0 {
+		t.Fatalf("got error estimate %v, wanted numerical integration", pred.AucError)
+	}
+
+	// known values
+	bf, _ := Bayesfactor(binomial(8, 11), PriorDefinition{Name: "beta", Params: []float64{2.5, 1}}, PriorDefinition{Name: "point", Params: []float64{0.5}})
+	compare(t, bf, 1/0.6632996)
+	bf, _ = Bayesfactor(normal(5.5, 32.35), PriorDefinition{Name: "normal", Params: []float64{0, 13.3, 0, inf}}, PriorDefinition{Name: "point", Params: []float64{0}})
+	compare(t, bf, 0.9745934)
+	bf, _ = Bayesfactor(poisson(3, 2), PriorDefinition{Name: "gamma", Params: []float64{2, 1}}, PriorDefinition{Name: "point", Params: []float64{1}})
+	compare(t, bf, 0.7297833)
+}
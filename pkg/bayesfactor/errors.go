@@ -0,0 +1,97 @@
+package bayesfactor
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Sentinel errors returned (wrapped in a *ModelError) when a likelihood or
+// prior definition is invalid. Use errors.Is to test for them.
+var (
+	ErrUnknownFamily  = errors.New("unknown distribution family")
+	ErrParamCount     = errors.New("wrong number of parameters")
+	ErrNonPositiveSD  = errors.New("sd/scale must be > 0")
+	ErrNonPositiveDF  = errors.New("df must be > 0")
+	ErrSampleSize     = errors.New("sample size too small")
+	ErrInvalidShape   = errors.New("shape parameters must be > 0")
+	ErrInvalidRange   = errors.New("min must be < max")
+	ErrInvalidCount   = errors.New("successes must be between 0 and trials")
+	ErrNotCount       = errors.New("count must be a whole number >= 0")
+	ErrCorrelation    = errors.New("correlation must be between -1 and 1")
+	ErrNonPositive    = errors.New("rate/exposure must be > 0")
+	ErrNotFinite      = errors.New("parameter must be finite")
+	ErrUndefinedRatio = errors.New("bayes factor is undefined")
+	ErrNoMass         = errors.New("prior has no mass between min and max")
+)
+
+// ModelError describes a problem with a likelihood or prior definition.
+type ModelError struct {
+	Kind   string // "likelihood" or "prior"
+	Family string
+	Param  string
+	Err    error
+}
+
+func (e *ModelError) Error() string {
+	if e.Param == "" {
+		return fmt.Sprintf("%s %q: %v", e.Kind, e.Family, e.Err)
+	}
+	return fmt.Sprintf("%s %q: %s: %v", e.Kind, e.Family, e.Param, e.Err)
+}
+
+func (e *ModelError) Unwrap() error {
+	return e.Err
+}
+
+// validator collects the first problem found with a definition
+type validator struct {
+	kind   string
+	family string
+	err    error
+}
+
+func (v *validator) fail(param string, err error) {
+	if v.err == nil {
+		v.err = &ModelError{Kind: v.kind, Family: v.family, Param: param, Err: err}
+	}
+}
+
+// count checks that there are as many params as one of the wanted counts
+func (v *validator) count(params []float64, want ...int) bool {
+	counts := make([]string, len(want))
+	for i, n := range want {
+		if len(params) == n {
+			return true
+		}
+		counts[i] = strconv.Itoa(n)
+	}
+	v.fail("", fmt.Errorf("%w: got %d, want %s", ErrParamCount, len(params), strings.Join(counts, " or ")))
+	return false
+}
+
+func (v *validator) finite(param string, x float64) {
+	if math.IsNaN(x) || math.IsInf(x, 0) {
+		v.fail(param, ErrNotFinite)
+	}
+}
+
+func (v *validator) positive(param string, x float64, err error) {
+	if !(x > 0) {
+		v.fail(param, err)
+	}
+}
+
+func (v *validator) whole(param string, x float64) {
+	if !(x >= 0) || math.IsInf(x, 0) || x != math.Floor(x) {
+		v.fail(param, ErrNotCount)
+	}
+}
+
+func (v *validator) interval(min float64, max float64) {
+	if math.IsNaN(min) || math.IsNaN(max) || !(min < max) {
+		v.fail("min/max", ErrInvalidRange)
+	}
+}
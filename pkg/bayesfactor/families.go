@@ -0,0 +1,433 @@
+package bayesfactor
+
+import (
+	"math"
+
+	. "pkg/distributions"
+)
+
+// The built in families. Prior families are built untruncated and
+// CreatePrior truncates them to their min and max parameters.
+
+func init() {
+
+	for name, e := range map[string]*entry{
+		"noncentral_d": {
+			params: []string{"d", "n"},
+			check: func(v *validator, params []float64) {
+				v.finite("d", params[0])
+				if !(params[1] > 1) {
+					v.fail("n", ErrSampleSize)
+				}
+				v.finite("n", params[1])
+			},
+			likelihood: func(params []float64) Likelihood {
+				d, n := params[0], params[1]
+				var data Likelihood
+				data.Function = NoncentralDLikelihood(d, n)
+				data.LogFunction = func(x float64) float64 {
+					return LogDt(d*math.Sqrt(n), n-1, math.Sqrt(n)*x)
+				}
+				data.center, data.scale = d, 1/math.Sqrt(n)
+				data.quantile = noncentralQuantile(d*math.Sqrt(n), n-1, 1/math.Sqrt(n))
+				return data
+			},
+		},
+
+		"noncentral_d2": {
+			params: []string{"d", "n1", "n2"},
+			check: func(v *validator, params []float64) {
+				v.finite("d", params[0])
+				v.positive("n1", params[1], ErrSampleSize)
+				v.finite("n1", params[1])
+				v.positive("n2", params[2], ErrSampleSize)
+				v.finite("n2", params[2])
+				if !(params[1]+params[2] > 2) {
+					v.fail("n1/n2", ErrSampleSize)
+				}
+			},
+			likelihood: func(params []float64) Likelihood {
+				d, n1, n2 := params[0], params[1], params[2]
+				var data Likelihood
+				data.Function = NoncentralD2Likelihood(d, n1, n2)
+				data.LogFunction = func(x float64) float64 {
+					return LogDt(d/math.Sqrt((1/n1)+(1/n2)), n1+n2-2, x*math.Sqrt((n1*n2)/(n1+n2)))
+				}
+				data.center, data.scale = d, math.Sqrt(1/n1+1/n2)
+				data.quantile = noncentralQuantile(d/math.Sqrt((1/n1)+(1/n2)), n1+n2-2, math.Sqrt(1/n1+1/n2))
+				return data
+			},
+		},
+
+		"normal": {
+			params: []string{"mean", "sd"},
+			check: func(v *validator, params []float64) {
+				v.finite("mean", params[0])
+				v.positive("sd", params[1], ErrNonPositiveSD)
+			},
+			likelihood: func(params []float64) Likelihood {
+				mean, sd := params[0], params[1]
+				var data Likelihood
+				data.Function = NormalLikelihood(mean, sd)
+				data.LogFunction = func(x float64) float64 {
+					return LogDnorm(x, mean, sd)
+				}
+				data.center, data.scale = mean, sd
+				data.quantile = func(p float64) float64 { return Qnorm(p, mean, sd, true, false) }
+				return data
+			},
+		},
+
+		"binomial": {
+			params: []string{"successes", "trials"},
+			check: func(v *validator, params []float64) {
+				v.positive("trials", params[1], ErrSampleSize)
+				v.finite("trials", params[1])
+				if !(params[0] >= 0 && params[0] <= params[1]) {
+					v.fail("successes", ErrInvalidCount)
+				}
+			},
+			likelihood: func(params []float64) Likelihood {
+				successes, trials := params[0], params[1]
+				var data Likelihood
+				data.Function = BinomialLikelihood(successes, trials)
+				data.LogFunction = func(x float64) float64 {
+					return LogDbinom(successes, trials, x)
+				}
+				p := successes / trials
+				data.center, data.scale = p, math.Sqrt((p*(1-p)+1/trials)/trials)
+				data.min, data.max, data.bounded = 0, 1, true
+				// the normalized likelihood is a beta(successes + 1,
+				// failures + 1) density
+				data.quantile = func(p float64) float64 {
+					return Qbeta(p, successes+1, trials-successes+1, true, false)
+				}
+				return data
+			},
+			observations: func(params []float64, min float64, max float64) []float64 {
+				return seqSteps(0, params[1]+1, 1)
+			},
+		},
+
+		"binomial2": {
+			params: []string{"successes1", "trials1", "successes2", "trials2"},
+			check:  checkBinomial2,
+			likelihood: func(params []float64) Likelihood {
+				y1, n1, y2, n2 := params[0], params[1], params[2], params[3]
+				var data Likelihood
+				data.LogFunction = logBinomial2(y1, n1, y2, n2)
+				data.Function = exponentiate(data.LogFunction)
+				data.center, data.scale = binomial2Estimate(y1, n1, y2, n2)
+				data.quantile = func(p float64) float64 {
+					return Qnorm(p, data.center, data.scale, true, false)
+				}
+				return data
+			},
+			observations: binomial2Observations,
+		},
+
+		"binomial2_diff": {
+			params: []string{"successes1", "trials1", "successes2", "trials2"},
+			check:  checkBinomial2,
+			likelihood: func(params []float64) Likelihood {
+				y1, n1, y2, n2 := params[0], params[1], params[2], params[3]
+				var data Likelihood
+				data.LogFunction = logBinomial2Diff(y1, n1, y2, n2)
+				data.Function = exponentiate(data.LogFunction)
+				data.center, data.scale = binomial2DiffEstimate(y1, n1, y2, n2)
+				data.min, data.max, data.bounded = -1, 1, true
+				data.quantile = func(p float64) float64 {
+					return Qnorm(p, data.center, data.scale, true, false)
+				}
+				return data
+			},
+			observations: binomial2Observations,
+		},
+
+		"correlation": {
+			params: []string{"r", "n"},
+			check:  checkCorrelation,
+			likelihood: func(params []float64) Likelihood {
+				r, n := params[0], params[1]
+				var data Likelihood
+				data.Function = CorrelationLikelihood(r, n)
+				data.LogFunction = func(x float64) float64 {
+					return LogDcor(r, x, n)
+				}
+				correlationRange(&data, r, n)
+				return data
+			},
+		},
+
+		"correlation_z": {
+			params: []string{"r", "n"},
+			check:  checkCorrelation,
+			likelihood: func(params []float64) Likelihood {
+				r, n := params[0], params[1]
+				var data Likelihood
+				data.Function = CorrelationZLikelihood(r, n)
+				data.LogFunction = func(x float64) float64 {
+					return LogDnorm(math.Atanh(r), math.Atanh(x), 1/math.Sqrt(n-3))
+				}
+				correlationRange(&data, r, n)
+				return data
+			},
+		},
+
+		"poisson": {
+			params: []string{"count", "exposure"},
+			check: func(v *validator, params []float64) {
+				v.whole("count", params[0])
+				v.positive("exposure", params[1], ErrNonPositive)
+				v.finite("exposure", params[1])
+			},
+			likelihood: func(params []float64) Likelihood {
+				count, exposure := params[0], params[1]
+				var data Likelihood
+				data.Function = PoissonLikelihood(count, exposure)
+				data.LogFunction = func(x float64) float64 {
+					return LogDpois(count, x*exposure)
+				}
+				data.center, data.scale = (count+1)/exposure, math.Sqrt(count+1)/exposure
+				data.min, data.max, data.bounded = 0, math.Inf(1), true
+				// the normalized likelihood is a gamma(count + 1,
+				// exposure) density
+				data.quantile = func(p float64) float64 {
+					return Qgamma(p, count+1, exposure, true, false)
+				}
+				return data
+			},
+			observations: rateObservations,
+		},
+
+		"negative_binomial": {
+			params: []string{"count", "size", "exposure"},
+			check: func(v *validator, params []float64) {
+				v.whole("count", params[0])
+				v.positive("size", params[1], ErrInvalidShape)
+				v.positive("exposure", params[2], ErrNonPositive)
+				v.finite("exposure", params[2])
+			},
+			likelihood: func(params []float64) Likelihood {
+				count, size, exposure := params[0], params[1], params[2]
+				var data Likelihood
+				data.Function = NegativeBinomialLikelihood(count, size, exposure)
+				data.LogFunction = func(x float64) float64 {
+					return LogDnbinom(count, size, x*exposure)
+				}
+				data.min, data.max, data.bounded = 0, math.Inf(1), true
+				// the normalized likelihood is approximated by a gamma
+				// density with the mean of the poisson case and its
+				// variance inflated by the overdispersion
+				inflation := 1 + (count+1)/size
+				shape, rate := (count+1)/inflation, exposure/inflation
+				data.center, data.scale = shape/rate, math.Sqrt(shape)/rate
+				data.quantile = func(p float64) float64 {
+					return Qgamma(p, shape, rate, true, false)
+				}
+				return data
+			},
+			observations: rateObservations,
+		},
+
+		"noncentral_t": {
+			params: []string{"t", "df"},
+			check: func(v *validator, params []float64) {
+				v.finite("t", params[0])
+				v.positive("df", params[1], ErrNonPositiveDF)
+				v.finite("df", params[1])
+			},
+			likelihood: func(params []float64) Likelihood {
+				t, df := params[0], params[1]
+				var data Likelihood
+				data.Function = NoncentralTLikelihood(t, df)
+				data.LogFunction = func(x float64) float64 {
+					return LogDt(t, df, x)
+				}
+				data.center, data.scale = t, 1
+				data.quantile = noncentralQuantile(t, df, 1)
+				return data
+			},
+		},
+
+		"student_t": {
+			params: []string{"mean", "sd", "df"},
+			check: func(v *validator, params []float64) {
+				v.finite("mean", params[0])
+				v.positive("sd", params[1], ErrNonPositiveSD)
+				v.positive("df", params[2], ErrNonPositiveDF)
+				v.finite("df", params[2])
+			},
+			likelihood: func(params []float64) Likelihood {
+				mean, sd, df := params[0], params[1], params[2]
+				var data Likelihood
+				data.Function = StudentTLikelihood(mean, sd, df)
+				data.LogFunction = func(x float64) float64 {
+					return LogScaled_shifted_t(x, mean, sd, df)
+				}
+				data.center, data.scale = mean, sd
+				data.quantile = func(p float64) float64 { return Qscaled_shifted_t(p, mean, sd, df, true, false) }
+				return data
+			},
+		},
+	} {
+		register(likelihoodFamilies, "likelihood", name, e)
+	}
+
+	inf := math.Inf(1)
+	for name, e := range map[string]*entry{
+		"cauchy": {
+			params: []string{"location", "scale", "min", "max"},
+			check: func(v *validator, params []float64) {
+				v.finite("location", params[0])
+				v.positive("scale", params[1], ErrNonPositiveSD)
+			},
+			prior: func(params []float64) Prior {
+				return CauchyPrior(params[0], params[1], -inf, inf)
+			},
+		},
+
+		"normal": {
+			params: []string{"mean", "sd", "min", "max"},
+			check: func(v *validator, params []float64) {
+				v.finite("mean", params[0])
+				v.positive("sd", params[1], ErrNonPositiveSD)
+			},
+			prior: func(params []float64) Prior {
+				return NormalPrior(params[0], params[1], -inf, inf)
+			},
+		},
+
+		"beta": {
+			params: []string{"alpha", "beta", "min", "max"},
+			check: func(v *validator, params []float64) {
+				v.positive("alpha", params[0], ErrInvalidShape)
+				v.positive("beta", params[1], ErrInvalidShape)
+			},
+			prior: func(params []float64) Prior {
+				return BetaPrior(params[0], params[1], 0, 1)
+			},
+		},
+
+		"uniform": {
+			params: []string{"minimum", "maximum", "min", "max"},
+			check: func(v *validator, params []float64) {
+				v.finite("minimum", params[0])
+				v.finite("maximum", params[1])
+				v.interval(params[0], params[1])
+			},
+			prior: func(params []float64) Prior {
+				return UniformPrior(params[0], params[1])
+			},
+		},
+
+		"student_t": {
+			params: []string{"mean", "sd", "df", "min", "max"},
+			check: func(v *validator, params []float64) {
+				v.finite("mean", params[0])
+				v.positive("sd", params[1], ErrNonPositiveSD)
+				v.positive("df", params[2], ErrNonPositiveDF)
+				v.finite("df", params[2])
+			},
+			prior: func(params []float64) Prior {
+				return StudentTPrior(params[0], params[1], params[2], -inf, inf)
+			},
+		},
+
+		"stretched_beta": {
+			params: []string{"alpha", "beta", "min", "max"},
+			check: func(v *validator, params []float64) {
+				v.positive("alpha", params[0], ErrInvalidShape)
+				v.positive("beta", params[1], ErrInvalidShape)
+			},
+			prior: func(params []float64) Prior {
+				return StretchedBetaPrior(params[0], params[1], -1, 1)
+			},
+		},
+
+		"gamma": {
+			params: []string{"shape", "rate", "min", "max"},
+			check: func(v *validator, params []float64) {
+				v.positive("shape", params[0], ErrInvalidShape)
+				v.finite("shape", params[0])
+				v.positive("rate", params[1], ErrNonPositive)
+				v.finite("rate", params[1])
+			},
+			prior: func(params []float64) Prior {
+				return GammaPrior(params[0], params[1], 0, inf)
+			},
+		},
+
+		"lognormal": {
+			params: []string{"meanlog", "sdlog", "min", "max"},
+			check: func(v *validator, params []float64) {
+				v.finite("meanlog", params[0])
+				v.positive("sdlog", params[1], ErrNonPositiveSD)
+			},
+			prior: func(params []float64) Prior {
+				return LogNormalPrior(params[0], params[1], 0, inf)
+			},
+		},
+
+		"point": {
+			params: []string{"point"},
+			check: func(v *validator, params []float64) {
+				v.finite("point", params[0])
+			},
+			prior: func(params []float64) Prior {
+				return PointPrior(params[0])
+			},
+		},
+	} {
+		register(priorFamilies, "prior", name, e)
+	}
+}
+
+// checkCorrelation checks the correlation and the number of pairs of a
+// correlation likelihood
+func checkCorrelation(v *validator, params []float64) {
+	if !(params[0] > -1 && params[0] < 1) {
+		v.fail("r", ErrCorrelation)
+	}
+	if !(params[1] > 3) {
+		v.fail("n", ErrSampleSize)
+	}
+	v.finite("n", params[1])
+}
+
+// correlationRange sets the support of a correlation likelihood to [-1, 1]
+// and its typical range from the fisher z approximation
+func correlationRange(data *Likelihood, r float64, n float64) {
+	z, sd := math.Atanh(r), 1/math.Sqrt(n-3)
+	data.center, data.scale = r, (1-r*r)*sd
+	data.min, data.max, data.bounded = -1, 1, true
+	data.quantile = func(p float64) float64 {
+		return math.Tanh(Qnorm(p, z, sd, true, false))
+	}
+}
+
+// checkBinomial2 checks the successes and trials of both groups of a two
+// proportion likelihood
+func checkBinomial2(v *validator, params []float64) {
+	for i, group := range []string{"1", "2"} {
+		successes, trials := params[2*i], params[2*i+1]
+		v.positive("trials"+group, trials, ErrSampleSize)
+		v.finite("trials"+group, trials)
+		if !(successes >= 0 && successes <= trials) {
+			v.fail("successes"+group, ErrInvalidCount)
+		}
+	}
+}
+
+// binomial2Observations are the successes in the first group, with the
+// second group as observed
+func binomial2Observations(params []float64, min float64, max float64) []float64 {
+	return seqCounts(params[1], params[0])
+}
+
+// rateObservations are the counts up to the expected count at a rate of
+// max, where the exposure is the last parameter
+func rateObservations(params []float64, min float64, max float64) []float64 {
+	count, exposure := params[0], params[len(params)-1]
+	return seqCounts(math.Max(count, math.Ceil(max*exposure)), count)
+}
@@ -0,0 +1,63 @@
+package bayesfactor
+
+import (
+	"math"
+	"sort"
+)
+
+// Observations returns the possible observations, from min to max, at
+// which the predictions of models with the likelihood are compared. The
+// observed value (the first parameter) is always included. Count
+// likelihoods give whole counts, up to the number of trials or to the
+// expected count at a rate of max, and other likelihoods give 101 equally
+// spaced values. Unknown families and definitions with the wrong number
+// of parameters give nil.
+func Observations(likelihood LikelihoodDefinition, min float64, max float64) []float64 {
+
+	family := likelihoodFamily(likelihood.Name)
+	if family == nil || len(likelihood.Params) != len(family.params) {
+		return nil
+	}
+	if family.observations != nil {
+		return family.observations(likelihood.Params, min, max)
+	}
+
+	observations := append(seqShort(min, max), likelihood.Params[0])
+	sort.Float64s(observations)
+	return observations
+}
+
+func seqShort(min float64, max float64) []float64 {
+	step := (max - min) / (100)
+	var values []float64
+	t := min
+	for i := min; i < max; i += step {
+		values = append(values, t)
+		t += step
+	}
+	values = append(values, max)
+
+	return values
+}
+
+func seqSteps(min float64, max float64, stepSize float64) []float64 {
+
+	var values []float64
+	for v := min; v < max; v += stepSize {
+		values = append(values, v)
+	}
+
+	return values
+}
+
+// seqCounts returns at most 101 whole numbers from 0 to max, including
+// current
+func seqCounts(max float64, current float64) []float64 {
+	step := math.Max(1, math.Ceil(max/100))
+	values := seqSteps(0, max+1, step)
+	if math.Mod(current, step) != 0 {
+		values = append(values, current)
+		sort.Float64s(values)
+	}
+	return values
+}
@@ -0,0 +1,51 @@
+package bayesfactor
+
+import (
+	"math"
+	"testing"
+)
+
+func TestObservations(t *testing.T) {
+
+	// continuous likelihoods give an equally spaced grid with the
+	// observation
+	got := Observations(LikelihoodDefinition{Name: "noncentral_d", Params: []float64{0.55, 80}}, -2, 2)
+	if len(got) != 102 || got[0] != -2 || got[101] != 2 {
+		t.Fatalf("got %v observations %v", len(got), got)
+	}
+	observed := false
+	for _, x := range got {
+		observed = observed || x == 0.55
+	}
+	if !observed {
+		t.Fatalf("got %v, wanted 0.55 in the observations", got)
+	}
+
+	// every count up to the number of trials
+	got = Observations(LikelihoodDefinition{Name: "binomial", Params: []float64{3, 10}}, 0, 1)
+	if len(got) != 11 || got[10] != 10 {
+		t.Fatalf("got %v observations %v", len(got), got)
+	}
+
+	// with many expected counts the observations are thinned out, but
+	// still include the observed count
+	got = Observations(LikelihoodDefinition{Name: "poisson", Params: []float64{37, 100}}, 0, 10)
+	if len(got) != 102 || got[1] != 10 || got[4] != 37 {
+		t.Fatalf("got %v observations %v", len(got), got)
+	}
+	for _, x := range got {
+		if x != math.Floor(x) {
+			t.Fatalf("got count %v", x)
+		}
+	}
+
+	// the successes in the first group
+	got = Observations(LikelihoodDefinition{Name: "binomial2", Params: []float64{10, 50, 18, 50}}, -1, 1)
+	if len(got) != 51 || got[50] != 50 {
+		t.Fatalf("got %v observations %v", len(got), got)
+	}
+
+	if got := Observations(LikelihoodDefinition{Name: "weibull", Params: []float64{1, 2}}, 0, 1); got != nil {
+		t.Fatalf("got %v, wanted nil for an unknown family", got)
+	}
+}
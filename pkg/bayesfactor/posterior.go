@@ -0,0 +1,314 @@
+package bayesfactor
+
+import (
+	"errors"
+	"math"
+	"sort"
+
+	. "pkg/distributions"
+)
+
+// ErrPosterior is returned when a posterior can't be normalized
+var ErrPosterior = errors.New("posterior is undefined")
+
+// number of intervals in the grid used to tabulate the posterior
+const posteriorGridSize = 4000
+
+// number of standard deviations either side of the mean covered by the grid
+const posteriorGridWidth = 12
+
+// Posterior is the posterior distribution obtained from a Predictive. The
+// density is tabulated on a fine grid covering the bulk of the posterior
+// which is used for the normalizing constant, CDF, quantile function and
+// summaries. The posterior for a point prior is a point mass.
+type Posterior struct {
+	function func(x float64) float64 // unnormalized density
+	z        float64                 // normalizing constant
+	x        []float64               // grid
+	f        []float64               // unnormalized density on the grid
+	cdf      []float64               // CDF on the grid
+	min      float64                 // lower bound of the support
+	max      float64                 // upper bound of the support
+	mean     float64
+	sd       float64
+	isPoint  bool
+}
+
+// PosteriorSummary is a summary of a posterior distribution with credible
+// intervals at the given level
+type PosteriorSummary struct {
+	Mean   float64    `json:"mean"`
+	Median float64    `json:"median"`
+	Mode   float64    `json:"mode"`
+	SD     float64    `json:"sd"`
+	Level  float64    `json:"level"`
+	ETI    [2]float64 `json:"eti"`
+	HDI    [2]float64 `json:"hdi"`
+}
+
+// NewPosterior builds the posterior distribution from the product of the
+// likelihood and prior in a Predictive returned by Pp
+func NewPosterior(pred Predictive) (Posterior, error) {
+
+	var post Posterior
+
+	if pred.isPoint {
+		post.isPoint = true
+		post.min, post.max = pred.point, pred.point
+		post.mean = pred.point
+		return post, nil
+	}
+
+	if math.IsInf(pred.LogAuc, 0) || math.IsNaN(pred.LogAuc) {
+		return post, ErrPosterior
+	}
+
+	// the density is normalized with the log marginal likelihood so that
+	// it doesn't underflow when the marginal likelihood does
+	post.function = func(x float64) float64 {
+		y := math.Exp(pred.LogFunction(x) - pred.LogAuc)
+		if math.IsNaN(y) {
+			return 0
+		}
+		return y
+	}
+
+	// use the moments to decide on the range of the grid. They are found
+	// with adaptive quadrature split at the peaks of the likelihood and
+	// prior so that sharp posteriors aren't missed. They only set the
+	// range, so an estimate that misses the tolerance is good enough.
+	mean, err := DefaultQuadrature.Integrate(func(x float64) float64 {
+		return x * post.function(x)
+	}, pred.min, pred.max, pred.breaks...)
+	if err == ErrIntegrand {
+		return post, ErrPosterior
+	}
+	variance, err := DefaultQuadrature.Integrate(func(x float64) float64 {
+		return (x - mean.Value) * (x - mean.Value) * post.function(x)
+	}, pred.min, pred.max, pred.breaks...)
+	if err == ErrIntegrand {
+		return post, ErrPosterior
+	}
+	sd := math.Sqrt(variance.Value)
+	if math.IsNaN(mean.Value) || math.IsNaN(sd) || math.IsInf(mean.Value, 0) || math.IsInf(sd, 0) {
+		return post, ErrPosterior
+	}
+	if sd == 0 {
+		sd = math.Abs(mean.Value)*1e-8 + 1e-12
+	}
+
+	post.min, post.max = pred.min, pred.max
+	lower := math.Max(pred.min, mean.Value-posteriorGridWidth*sd)
+	upper := math.Min(pred.max, mean.Value+posteriorGridWidth*sd)
+
+	// tabulate the density and integrate with the trapezoidal rule
+	n := posteriorGridSize
+	post.x = make([]float64, n+1)
+	post.f = make([]float64, n+1)
+	post.cdf = make([]float64, n+1)
+	step := (upper - lower) / float64(n)
+	for i := 0; i <= n; i++ {
+		post.x[i] = lower + float64(i)*step
+		post.f[i] = post.function(post.x[i])
+		if i > 0 {
+			post.cdf[i] = post.cdf[i-1] + step*(post.f[i-1]+post.f[i])/2
+		}
+	}
+
+	post.z = post.cdf[n]
+	if !(post.z > 0) || math.IsInf(post.z, 0) {
+		return post, ErrPosterior
+	}
+
+	for i := range post.cdf {
+		post.cdf[i] /= post.z
+	}
+
+	// moments of the tabulated posterior
+	var m1, v float64
+	for i := 1; i <= n; i++ {
+		xm := (post.x[i-1] + post.x[i]) / 2
+		w := post.cdf[i] - post.cdf[i-1]
+		m1 += xm * w
+	}
+	for i := 1; i <= n; i++ {
+		xm := (post.x[i-1] + post.x[i]) / 2
+		w := post.cdf[i] - post.cdf[i-1]
+		v += (xm - m1) * (xm - m1) * w
+	}
+	post.mean = m1
+	post.sd = math.Sqrt(v)
+
+	return post, nil
+}
+
+// Density returns the normalized posterior density at x. For a point mass
+// the density is +Inf at the point and 0 elsewhere.
+func (p Posterior) Density(x float64) float64 {
+	if p.isPoint {
+		if x == p.mean {
+			return math.Inf(1)
+		}
+		return 0
+	}
+	if x < p.min || x > p.max {
+		return 0
+	}
+	return p.function(x) / p.z
+}
+
+// CDF returns the posterior probability that the parameter is <= x
+func (p Posterior) CDF(x float64) float64 {
+	if p.isPoint {
+		if x < p.mean {
+			return 0
+		}
+		return 1
+	}
+	n := len(p.x) - 1
+	if x < p.x[0] {
+		return 0
+	}
+	if x >= p.x[n] {
+		return 1
+	}
+
+	// find the grid interval containing x and integrate the linearly
+	// interpolated density over the part of the interval below x
+	i := sort.SearchFloat64s(p.x, x)
+	if i == 0 {
+		return 0
+	}
+	x0, x1 := p.x[i-1], p.x[i]
+	f0, f1 := p.f[i-1], p.f[i]
+	fx := f0 + (f1-f0)*(x-x0)/(x1-x0)
+	return p.cdf[i-1] + (x-x0)*(f0+fx)/2/p.z
+}
+
+// Quantile returns the value of the parameter with CDF(x) = prob
+func (p Posterior) Quantile(prob float64) float64 {
+	if math.IsNaN(prob) || prob < 0 || prob > 1 {
+		return math.NaN()
+	}
+	if p.isPoint {
+		return p.mean
+	}
+
+	i := sort.SearchFloat64s(p.cdf, prob)
+	if i == 0 {
+		return p.x[0]
+	}
+	if i >= len(p.cdf) {
+		return p.x[len(p.x)-1]
+	}
+	c0, c1 := p.cdf[i-1], p.cdf[i]
+	if c1 == c0 {
+		return p.x[i-1]
+	}
+	return p.x[i-1] + (p.x[i]-p.x[i-1])*(prob-c0)/(c1-c0)
+}
+
+// Mean returns the posterior mean
+func (p Posterior) Mean() float64 {
+	return p.mean
+}
+
+// Median returns the posterior median
+func (p Posterior) Median() float64 {
+	return p.Quantile(0.5)
+}
+
+// SD returns the posterior standard deviation
+func (p Posterior) SD() float64 {
+	return p.sd
+}
+
+// Mode returns the posterior mode
+func (p Posterior) Mode() float64 {
+	if p.isPoint {
+		return p.mean
+	}
+
+	best := 0
+	for i, f := range p.f {
+		if f > p.f[best] {
+			best = i
+		}
+	}
+	if best == 0 || best == len(p.f)-1 {
+		return p.x[best]
+	}
+
+	// refine with a parabola through the neighbouring points
+	f0, f1, f2 := p.f[best-1], p.f[best], p.f[best+1]
+	denom := f0 - 2*f1 + f2
+	if denom == 0 {
+		return p.x[best]
+	}
+	step := p.x[best+1] - p.x[best]
+	return p.x[best] + step*(f0-f2)/(2*denom)
+}
+
+// EqualTailedInterval returns the central credible interval containing
+// the given probability mass (e.g. 0.95)
+func (p Posterior) EqualTailedInterval(level float64) (float64, float64) {
+	if !(level > 0 && level < 1) {
+		return math.NaN(), math.NaN()
+	}
+	return p.Quantile((1 - level) / 2), p.Quantile((1 + level) / 2)
+}
+
+// HighestDensityInterval returns the shortest credible interval containing
+// the given probability mass (e.g. 0.95). The posterior is assumed to be
+// unimodal.
+func (p Posterior) HighestDensityInterval(level float64) (float64, float64) {
+	if !(level > 0 && level < 1) {
+		return math.NaN(), math.NaN()
+	}
+	if p.isPoint {
+		return p.mean, p.mean
+	}
+
+	// for a unimodal density the HDI bounds have equal density. The
+	// difference between the density at the upper and lower bounds
+	// decreases with the lower tail probability so find the root by
+	// bisection.
+	diff := func(lower float64) float64 {
+		return p.Density(p.Quantile(lower+level)) - p.Density(p.Quantile(lower))
+	}
+
+	a, b := 0.0, 1-level
+	var lower float64
+	switch {
+	case diff(a) <= 0:
+		lower = a
+	case diff(b) >= 0:
+		lower = b
+	default:
+		for i := 0; i < 100 && b-a > 1e-12; i++ {
+			mid := (a + b) / 2
+			if diff(mid) > 0 {
+				a = mid
+			} else {
+				b = mid
+			}
+		}
+		lower = (a + b) / 2
+	}
+
+	return p.Quantile(lower), p.Quantile(lower + level)
+}
+
+// Summary returns the posterior summaries with credible intervals at the
+// given level
+func (p Posterior) Summary(level float64) PosteriorSummary {
+	var s PosteriorSummary
+	s.Mean = p.Mean()
+	s.Median = p.Median()
+	s.Mode = p.Mode()
+	s.SD = p.SD()
+	s.Level = level
+	s.ETI[0], s.ETI[1] = p.EqualTailedInterval(level)
+	s.HDI[0], s.HDI[1] = p.HighestDensityInterval(level)
+	return s
+}
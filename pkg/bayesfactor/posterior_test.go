@@ -0,0 +1,106 @@
+package bayesfactor
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPosterior(t *testing.T) {
+
+	// normal likelihood with a normal prior gives a normal posterior
+	// with mean 2.5 and sd sqrt(50)
+	likelihood := LikelihoodDefinition{Name: "normal", Params: []float64{5, 10}}
+	prior := PriorDefinition{Name: "normal", Params: []float64{0, 10, math.Inf(-1), math.Inf(1)}}
+	pred, _ := Pp(likelihood, prior)
+	post, err := NewPosterior(pred)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+
+	sd := math.Sqrt(50)
+	compare(t, post.Mean(), 2.5)
+	compare(t, post.Median(), 2.5)
+	compare(t, post.Mode(), 2.5)
+	compare(t, post.SD(), sd)
+	compare(t, post.Density(2.5), 1/(sd*math.Sqrt(2*math.Pi)))
+	compare(t, post.CDF(2.5+sd), 0.8413447)
+	compare(t, post.Quantile(0.8413447), 2.5+sd)
+
+	lower, upper := post.EqualTailedInterval(0.95)
+	compare(t, lower, 2.5-1.959964*sd)
+	compare(t, upper, 2.5+1.959964*sd)
+	lower, upper = post.HighestDensityInterval(0.95)
+	compare(t, lower, 2.5-1.959964*sd)
+	compare(t, upper, 2.5+1.959964*sd)
+
+	// binomial likelihood with a beta prior gives a beta(10.5, 4)
+	// posterior
+	likelihood = LikelihoodDefinition{Name: "binomial", Params: []float64{8, 11}}
+	prior = PriorDefinition{Name: "beta", Params: []float64{2.5, 1}}
+	pred, _ = Pp(likelihood, prior)
+	post, err = NewPosterior(pred)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+
+	summary := post.Summary(0.95)
+	compare(t, summary.Mean, 10.5/14.5)
+	compare(t, summary.Mode, 9.5/12.5)
+	compare(t, summary.SD, 0.1135250)
+	compare(t, summary.Median, 0.7346665)
+	compare(t, summary.ETI[0], 0.4773596)
+	compare(t, summary.ETI[1], 0.9127372)
+	compare(t, summary.HDI[0], 0.5019659)
+	compare(t, summary.HDI[1], 0.9291282)
+
+	// half-normal prior: the mode is at the bound and the HDI starts at 0
+	likelihood = LikelihoodDefinition{Name: "normal", Params: []float64{-0.5, 1}}
+	prior = PriorDefinition{Name: "normal", Params: []float64{0, 1, 0, math.Inf(1)}}
+	pred, _ = Pp(likelihood, prior)
+	post, err = NewPosterior(pred)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	if post.Mode() != 0 || post.CDF(0) != 0 || post.Density(-0.1) != 0 {
+		t.Fatalf("got mode %v, CDF(0) %v", post.Mode(), post.CDF(0))
+	}
+	lower, _ = post.HighestDensityInterval(0.95)
+	if lower != 0 {
+		t.Fatalf("got HDI lower bound %v, wanted 0", lower)
+	}
+
+	// point prior gives a point mass
+	prior = PriorDefinition{Name: "point", Params: []float64{0.3}}
+	pred, _ = Pp(likelihood, prior)
+	post, err = NewPosterior(pred)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	summary = post.Summary(0.95)
+	if summary.Mean != 0.3 || summary.Median != 0.3 || summary.SD != 0 || summary.HDI != [2]float64{0.3, 0.3} {
+		t.Fatalf("got %+v, wanted a point mass at 0.3", summary)
+	}
+	if post.CDF(0.29) != 0 || post.CDF(0.3) != 1 {
+		t.Fatalf("got CDF %v and %v", post.CDF(0.29), post.CDF(0.3))
+	}
+
+	// a sharp likelihood far from the prior mean: the posterior is normal
+	// with precision 1 / 0.01^2 + 1 / 10^2
+	likelihood = LikelihoodDefinition{Name: "normal", Params: []float64{20, 0.01}}
+	prior = PriorDefinition{Name: "normal", Params: []float64{0, 10, math.Inf(-1), math.Inf(1)}}
+	for _, numeric := range []bool{false, true} {
+		opts := DefaultOptions
+		opts.Numeric = numeric
+		pred, err := PpWithOptions(likelihood, prior, opts)
+		if err != nil {
+			t.Fatalf("got error %v", err)
+		}
+		post, err := NewPosterior(pred)
+		if err != nil {
+			t.Fatalf("numeric %v: got error %v", numeric, err)
+		}
+		precision := 1/(0.01*0.01) + 1/(10.0*10.0)
+		compare(t, post.Mean(), 20/(0.01*0.01)/precision)
+		compare(t, post.SD(), 1/math.Sqrt(precision))
+	}
+}
@@ -0,0 +1,259 @@
+package bayesfactor
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"golang.org/x/exp/rand"
+)
+
+// Family describes a likelihood or prior family that can be added with
+// RegisterLikelihood or RegisterPrior. Apart from Params, each method is
+// given one parameter for each name returned by Params, and apart from
+// Validate the parameters are valid.
+type Family interface {
+	// Params returns the names of the parameters in the order they appear
+	// in the Params of a definition. If the last two names of a prior
+	// family are "min" and "max", the prior is truncated to those bounds,
+	// which can be left out of a definition.
+	Params() []string
+
+	// Validate checks the parameters. A *ModelError is returned as is,
+	// with its Kind and Family filled in, and any other error is wrapped
+	// in one.
+	Validate(params []float64) error
+
+	// LogFunction returns the log likelihood, or the log density of a
+	// prior, as a function of the parameter. Prior densities must be
+	// normalized over the support.
+	LogFunction(params []float64) func(x float64) float64
+
+	// Support returns the bounds of the parameter
+	Support(params []float64) (float64, float64)
+
+	// TypicalRange returns the range of the parameter that holds the bulk
+	// of the likelihood or prior, which is used to plot it and to guide the
+	// integration
+	TypicalRange(params []float64) (float64, float64)
+}
+
+// Sampler is implemented by prior families that can draw from the prior,
+// for Prior.Rand
+type Sampler interface {
+	Random(params []float64) func(src rand.Source) float64
+}
+
+// Cumulative is implemented by prior families with a CDF and quantile
+// function (with the signatures used by the P* and Q* functions in
+// pkg/distributions), so that truncated priors are normalized exactly
+type Cumulative interface {
+	CDF(params []float64) func(q float64, lowerTail bool, logP bool) float64
+	Quantile(params []float64) func(p float64, lowerTail bool, logP bool) float64
+}
+
+// entry is a registered family
+type entry struct {
+	params       []string
+	check        func(v *validator, params []float64)
+	likelihood   func(params []float64) Likelihood
+	prior        func(params []float64) Prior
+	observations func(params []float64, min float64, max float64) []float64 // whole counts, see Observations
+}
+
+// bounded reports whether the last two parameters are the min and max
+// bounds of a prior, which can then be left out
+func (e *entry) bounded() bool {
+	n := len(e.params)
+	return n >= 2 && e.params[n-2] == "min" && e.params[n-1] == "max"
+}
+
+// complete adds the default bounds to params if they were left out
+func (e *entry) complete(params []float64) []float64 {
+	if len(params) == len(e.params)-2 && e.bounded() {
+		return append(append([]float64(nil), params...), math.Inf(-1), math.Inf(1))
+	}
+	return params
+}
+
+// validate checks the number of parameters, the parameters themselves,
+// and the bounds of a prior
+func (e *entry) validate(kind string, name string, params []float64) error {
+
+	v := validator{kind: kind, family: name}
+	counts := []int{len(e.params)}
+	if e.bounded() {
+		counts = []int{len(e.params) - 2, len(e.params)}
+	}
+	if !v.count(params, counts...) {
+		return v.err
+	}
+
+	params = e.complete(params)
+	e.check(&v, params)
+	if n := len(params); kind == "prior" && e.bounded() {
+		v.interval(params[n-2], params[n-1])
+	}
+	return v.err
+}
+
+var (
+	registry           sync.RWMutex
+	likelihoodFamilies = map[string]*entry{}
+	priorFamilies      = map[string]*entry{}
+)
+
+// RegisterLikelihood makes a likelihood family available under name in
+// likelihood definitions and model specs, so that it can be used with
+// Bayesfactor, Pp and everything built on them. It panics if the name is
+// already registered, and is usually called from the init function of the
+// package that defines the family.
+func RegisterLikelihood(name string, family Family) {
+	register(likelihoodFamilies, "likelihood", name, &entry{
+		params: family.Params(),
+		check:  checkFamily(family),
+		likelihood: func(params []float64) Likelihood {
+			return familyLikelihood(family, params)
+		},
+	})
+}
+
+// RegisterPrior makes a prior family available under name in prior
+// definitions and model specs, like RegisterLikelihood
+func RegisterPrior(name string, family Family) {
+	register(priorFamilies, "prior", name, &entry{
+		params: family.Params(),
+		check:  checkFamily(family),
+		prior: func(params []float64) Prior {
+			return familyPrior(family, params)
+		},
+	})
+}
+
+func register(families map[string]*entry, kind string, name string, e *entry) {
+	registry.Lock()
+	defer registry.Unlock()
+	if _, ok := families[name]; ok {
+		panic(fmt.Sprintf("bayesfactor: %s family %q registered twice", kind, name))
+	}
+	families[name] = e
+}
+
+func likelihoodFamily(name string) *entry {
+	registry.RLock()
+	defer registry.RUnlock()
+	return likelihoodFamilies[name]
+}
+
+func priorFamily(name string) *entry {
+	registry.RLock()
+	defer registry.RUnlock()
+	return priorFamilies[name]
+}
+
+// LikelihoodFamilies returns the names of the registered likelihood
+// families in alphabetical order
+func LikelihoodFamilies() []string {
+	return familyNames(likelihoodFamilies)
+}
+
+// PriorFamilies returns the names of the registered prior families in
+// alphabetical order
+func PriorFamilies() []string {
+	return familyNames(priorFamilies)
+}
+
+func familyNames(families map[string]*entry) []string {
+	registry.RLock()
+	defer registry.RUnlock()
+	names := make([]string, 0, len(families))
+	for name := range families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func validateLikelihood(likelihood LikelihoodDefinition) error {
+	e := likelihoodFamily(likelihood.Name)
+	if e == nil {
+		return &ModelError{Kind: "likelihood", Family: likelihood.Name, Err: ErrUnknownFamily}
+	}
+	return e.validate("likelihood", likelihood.Name, likelihood.Params)
+}
+
+func validatePrior(prior PriorDefinition) error {
+	e := priorFamily(prior.Name)
+	if e == nil {
+		return &ModelError{Kind: "prior", Family: prior.Name, Err: ErrUnknownFamily}
+	}
+	return e.validate("prior", prior.Name, prior.Params)
+}
+
+// checkFamily adapts the Validate method of a family
+func checkFamily(family Family) func(v *validator, params []float64) {
+	return func(v *validator, params []float64) {
+		err := family.Validate(params)
+		var modelErr *ModelError
+		switch {
+		case err == nil:
+		case errors.As(err, &modelErr):
+			v.fail(modelErr.Param, modelErr.Err)
+		default:
+			v.fail("", err)
+		}
+	}
+}
+
+// familyLikelihood builds a likelihood from a registered family. The
+// breakpoints for the integration are spread over the typical range.
+func familyLikelihood(family Family, params []float64) Likelihood {
+
+	logFunction := family.LogFunction(params)
+	lower, upper := family.TypicalRange(params)
+
+	var likelihood Likelihood
+	likelihood.LogFunction = logFunction
+	likelihood.Function = exponentiate(logFunction)
+	likelihood.min, likelihood.max = family.Support(params)
+	likelihood.bounded = true
+	likelihood.center, likelihood.scale = (lower+upper)/2, (upper-lower)/8
+	return likelihood
+}
+
+// familyPrior builds the untruncated prior from a registered family. With
+// a CDF and quantile function it is truncated like the built in priors,
+// otherwise Truncate normalizes it numerically.
+func familyPrior(family Family, params []float64) Prior {
+
+	logFunction := family.LogFunction(params)
+	lower, upper := family.TypicalRange(params)
+
+	var prior Prior
+	prior.LogFunction = logFunction
+	prior.Function = exponentiate(logFunction)
+	prior.min, prior.max = family.Support(params)
+	prior.bounded = true
+	prior.center, prior.scale = (lower+upper)/2, (upper-lower)/8
+	if sampler, ok := family.(Sampler); ok {
+		prior.sampler = sampler.Random(params)
+	}
+	if cumulative, ok := family.(Cumulative); ok {
+		prior.dist = &distribution{
+			logDensity: logFunction,
+			random:     prior.sampler,
+			cdf:        cumulative.CDF(params),
+			quantile:   cumulative.Quantile(params),
+		}
+	}
+	return prior
+}
+
+// exponentiate returns the function exp(logFunction(x))
+func exponentiate(logFunction func(x float64) float64) func(x float64) float64 {
+	return func(x float64) float64 {
+		return math.Exp(logFunction(x))
+	}
+}
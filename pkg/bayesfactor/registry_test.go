@@ -0,0 +1,228 @@
+package bayesfactor
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/exp/rand"
+
+	"pkg/distributions"
+)
+
+// laplace is a laplace family with a location and scale, and optionally
+// min and max bounds
+type laplace struct {
+	bounds bool
+}
+
+func (f laplace) Params() []string {
+	if f.bounds {
+		return []string{"location", "scale", "min", "max"}
+	}
+	return []string{"location", "scale"}
+}
+
+func (f laplace) Validate(params []float64) error {
+	if math.IsNaN(params[0]) {
+		return errors.New("location is NaN")
+	}
+	if !(params[1] > 0) {
+		return &ModelError{Param: "scale", Err: ErrNonPositiveSD}
+	}
+	return nil
+}
+
+func (f laplace) LogFunction(params []float64) func(x float64) float64 {
+	location, scale := params[0], params[1]
+	return func(x float64) float64 {
+		return -math.Abs(x-location)/scale - math.Log(2*scale)
+	}
+}
+
+func (f laplace) Support(params []float64) (float64, float64) {
+	return math.Inf(-1), math.Inf(1)
+}
+
+func (f laplace) TypicalRange(params []float64) (float64, float64) {
+	return params[0] - 10*params[1], params[0] + 10*params[1]
+}
+
+// laplaceCDF is a laplace family with a CDF, quantile function and random
+// variates
+type laplaceCDF struct {
+	laplace
+}
+
+func (f laplaceCDF) CDF(params []float64) func(q float64, lowerTail bool, logP bool) float64 {
+	location, scale := params[0], params[1]
+	return func(q float64, lowerTail bool, logP bool) float64 {
+		z := (q - location) / scale
+		if !lowerTail {
+			z = -z
+		}
+		logp := z - math.Ln2
+		if z > 0 {
+			logp = math.Log1p(-math.Exp(-z) / 2)
+		}
+		if logP {
+			return logp
+		}
+		return math.Exp(logp)
+	}
+}
+
+func (f laplaceCDF) Quantile(params []float64) func(p float64, lowerTail bool, logP bool) float64 {
+	location, scale := params[0], params[1]
+	return func(p float64, lowerTail bool, logP bool) float64 {
+		if logP {
+			p = math.Exp(p)
+		}
+		if !lowerTail {
+			p = 1 - p
+		}
+		if p < 0.5 {
+			return location + scale*math.Log(2*p)
+		}
+		return location - scale*math.Log(2*(1-p))
+	}
+}
+
+func (f laplaceCDF) Random(params []float64) func(src rand.Source) float64 {
+	return func(src rand.Source) float64 {
+		u := distributions.Runif(0, 1, src)
+		return f.Quantile(params)(u, true, false)
+	}
+}
+
+func init() {
+	RegisterLikelihood("test_laplace", laplace{})
+	RegisterPrior("test_laplace", laplace{bounds: true})
+	RegisterPrior("test_laplace_cdf", laplaceCDF{laplace{bounds: true}})
+}
+
+func TestRegistry(t *testing.T) {
+
+	inf := math.Inf(1)
+
+	// a custom likelihood with a point prior and a built in prior
+	likelihood := LikelihoodDefinition{Name: "test_laplace", Params: []float64{0.3, 0.2}}
+	pred, err := Pp(likelihood, PriorDefinition{Name: "point", Params: []float64{0}})
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	compare(t, pred.LogAuc, -1.5-math.Log(0.4))
+
+	pred, err = Pp(likelihood, PriorDefinition{Name: "normal", Params: []float64{0, 1, -inf, inf}})
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	want, err := distributions.IntegrateAdaptive(func(x float64) float64 {
+		return math.Exp(-math.Abs(x-0.3)/0.2) / 0.4 * distributions.Dnorm(x, 0, 1)
+	}, -inf, inf, 0.3)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	compare(t, pred.Auc, want.Value)
+
+	created, _ := CreateLikelihood(likelihood)
+	min, max := created.TypicalRange()
+	compare(t, min, -1.7)
+	compare(t, max, 2.3)
+
+	// custom priors truncated to [0, Inf) are exponential priors, and with
+	// a normal likelihood with a mean and sd of 1 the marginal likelihood
+	// is exp(-1/2) / 2
+	normal := LikelihoodDefinition{Name: "normal", Params: []float64{1, 1}}
+	for _, name := range []string{"test_laplace", "test_laplace_cdf"} {
+		prior := PriorDefinition{Name: name, Params: []float64{0, 1, 0, inf}}
+		pred, err := Pp(normal, prior)
+		if err != nil {
+			t.Fatalf("%v: got error %v", name, err)
+		}
+		compare(t, pred.Auc, math.Exp(-0.5)/2)
+
+		created, err := CreatePrior(prior)
+		if err != nil {
+			t.Fatalf("%v: got error %v", name, err)
+		}
+		compare(t, created.Function(1), math.Exp(-1))
+	}
+
+	// the bounds of custom priors can be left out
+	bf, err := Bayesfactor(normal, PriorDefinition{Name: "test_laplace_cdf", Params: []float64{0, 1}}, PriorDefinition{Name: "point", Params: []float64{0}})
+	if err != nil || !(bf > 0) {
+		t.Fatalf("got bf %v and error %v", bf, err)
+	}
+
+	// and they are sampled within their bounds
+	prior, _ := CreatePrior(PriorDefinition{Name: "test_laplace_cdf", Params: []float64{0, 1, 1, 2}})
+	src := rand.NewSource(1)
+	for i := 0; i < 100; i++ {
+		if x := prior.Rand(src); !(x >= 1 && x <= 2) {
+			t.Fatalf("got %v outside of [1, 2]", x)
+		}
+	}
+
+	// the errors of custom families are model errors
+	cases := []struct {
+		prior PriorDefinition
+		param string
+		want  error
+	}{
+		{PriorDefinition{Name: "test_laplace", Params: []float64{0, -1}}, "scale", ErrNonPositiveSD},
+		{PriorDefinition{Name: "test_laplace", Params: []float64{0, 1, 2, 1}}, "min/max", ErrInvalidRange},
+		{PriorDefinition{Name: "test_laplace", Params: []float64{0, 1, 2}}, "", ErrParamCount},
+	}
+	for _, c := range cases {
+		_, err := CreatePrior(c.prior)
+		var modelErr *ModelError
+		if !errors.Is(err, c.want) || !errors.As(err, &modelErr) {
+			t.Fatalf("got error %v, wanted %v", err, c.want)
+		}
+		if modelErr.Kind != "prior" || modelErr.Family != "test_laplace" || modelErr.Param != c.param {
+			t.Fatalf("got %#v", modelErr)
+		}
+	}
+	_, err = CreateLikelihood(LikelihoodDefinition{Name: "test_laplace", Params: []float64{math.NaN(), 1}})
+	var modelErr *ModelError
+	if !errors.As(err, &modelErr) || modelErr.Kind != "likelihood" {
+		t.Fatalf("got error %v, wanted a likelihood error", err)
+	}
+
+	// specs with custom families can be saved and loaded
+	spec := NewModelSpec(likelihood, PriorDefinition{Name: "test_laplace", Params: []float64{0, 1, 0, inf}}, PriorDefinition{Name: "point", Params: []float64{0}})
+	data, err := json.Marshal(spec)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	got, err := ParseModelSpec(data)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	if !cmp.Equal(got, spec) {
+		t.Fatalf("got %v, wanted %v", got, spec)
+	}
+	if names := PriorParams("test_laplace"); !cmp.Equal(names, []string{"location", "scale", "min", "max"}) {
+		t.Fatalf("got %v", names)
+	}
+
+	found := false
+	for _, name := range LikelihoodFamilies() {
+		found = found || name == "test_laplace"
+	}
+	if !found {
+		t.Fatalf("got %v, wanted test_laplace to be registered", LikelihoodFamilies())
+	}
+}
+
+func TestRegisterTwice(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("registering a family twice didn't panic")
+		}
+	}()
+	RegisterPrior("normal", laplace{})
+}
@@ -0,0 +1,79 @@
+package bayesfactor
+
+import (
+	"errors"
+	"math"
+)
+
+// ErrNotNested is returned by SavageDickey when the alternative prior has
+// no density at the null value, so the point null isn't nested in the
+// alternative model
+var ErrNotNested = errors.New("point null is not nested in the alternative prior")
+
+// SavageDickeyCheck compares BF01 computed as the ratio of the marginal
+// likelihoods (as in Bayesfactor) with BF01 computed using the
+// Savage-Dickey density ratio
+type SavageDickeyCheck struct {
+	Integration  float64 `json:"integration"`
+	SavageDickey float64 `json:"savageDickey"`
+	AbsDiff      float64 `json:"absDiff"`
+	RelDiff      float64 `json:"relDiff"`
+}
+
+// SavageDickey computes BF01 for a point null at point nested in the
+// alternative model as the ratio of the posterior density to the prior
+// density at point. The posterior is normalized on its own grid (see
+// Posterior) rather than with the marginal likelihood, so this is an
+// independent check on the integration used by Bayesfactor.
+func SavageDickey(likelihood LikelihoodDefinition, altprior PriorDefinition, point float64) (float64, error) {
+
+	altprior = SupportPrior(altprior, likelihood)
+	prior, err := CreatePrior(altprior)
+	if err != nil {
+		return math.NaN(), err
+	}
+	if prior.Name == "point" {
+		return math.NaN(), ErrNotNested
+	}
+
+	priorDensity := prior.Function(point)
+	if !(priorDensity > 0) || math.IsInf(priorDensity, 0) {
+		return math.NaN(), ErrNotNested
+	}
+
+	pred, err := Pp(likelihood, altprior)
+	if err != nil {
+		return math.NaN(), err
+	}
+	posterior, err := NewPosterior(pred)
+	if err != nil {
+		return math.NaN(), err
+	}
+
+	return posterior.Density(point) / priorDensity, nil
+}
+
+// CrossCheckSavageDickey computes BF01 using both the ratio of marginal
+// likelihoods and the Savage-Dickey density ratio and reports the
+// discrepancy between the two
+func CrossCheckSavageDickey(likelihood LikelihoodDefinition, altprior PriorDefinition, point float64) (SavageDickeyCheck, error) {
+
+	var check SavageDickeyCheck
+
+	sd, err := SavageDickey(likelihood, altprior, point)
+	if err != nil {
+		return check, err
+	}
+
+	nullprior := PriorDefinition{Name: "point", Params: []float64{point}}
+	bf10, err := Bayesfactor(likelihood, altprior, nullprior)
+	if err != nil {
+		return check, err
+	}
+
+	check.Integration = 1 / bf10
+	check.SavageDickey = sd
+	check.AbsDiff = math.Abs(check.Integration - check.SavageDickey)
+	check.RelDiff = check.AbsDiff / math.Abs(check.Integration)
+	return check, nil
+}
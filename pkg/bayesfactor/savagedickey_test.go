@@ -0,0 +1,60 @@
+package bayesfactor
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestSavageDickey(t *testing.T) {
+
+	inf := math.Inf(1)
+
+	// values from the BayesFactor R package (see TestBayesfactor)
+	likelihood := LikelihoodDefinition{Name: "noncentral_d", Params: []float64{2.03 / math.Sqrt(80), 80}}
+	altprior := PriorDefinition{Name: "cauchy", Params: []float64{0, 1, -inf, inf}}
+	bf01, err := SavageDickey(likelihood, altprior, 0)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	compare(t, bf01, 1.557447)
+
+	// normal likelihood with a normal prior
+	likelihood = LikelihoodDefinition{Name: "normal", Params: []float64{5, 10}}
+	altprior = PriorDefinition{Name: "normal", Params: []float64{0, 10, -inf, inf}}
+	bf01, err = SavageDickey(likelihood, altprior, 0)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	compare(t, bf01, 0.03520653/0.02650035)
+
+	// both methods should agree
+	likelihood = LikelihoodDefinition{Name: "student_t", Params: []float64{5.47, 32.2, 119}}
+	altprior = PriorDefinition{Name: "student_t", Params: []float64{13.3, 4.93, 72, -inf, inf}}
+	check, err := CrossCheckSavageDickey(likelihood, altprior, 0)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	compare(t, check.Integration, 1/0.9738)
+	if check.RelDiff > 0.001 {
+		t.Fatalf("got %+v, wanted a relative difference < 0.001", check)
+	}
+
+	// the null value must be in the support of the alternative prior
+	altprior = PriorDefinition{Name: "uniform", Params: []float64{1, 2}}
+	if _, err := SavageDickey(likelihood, altprior, 0); !errors.Is(err, ErrNotNested) {
+		t.Fatalf("got error %v, wanted %v", err, ErrNotNested)
+	}
+
+	// the prior is restricted to the support of the likelihood by both
+	// methods
+	likelihood = LikelihoodDefinition{Name: "binomial", Params: []float64{2, 10}}
+	altprior = PriorDefinition{Name: "normal", Params: []float64{0.5, 1, -inf, inf}}
+	check, err = CrossCheckSavageDickey(likelihood, altprior, 0.5)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	if check.RelDiff > 0.001 {
+		t.Fatalf("got %+v, wanted a relative difference < 0.001", check)
+	}
+}
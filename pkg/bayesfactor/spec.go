@@ -0,0 +1,229 @@
+package bayesfactor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// SpecVersion is the current version of the JSON model specification
+const SpecVersion = 1
+
+// Errors returned when decoding a JSON model specification
+var (
+	ErrMissingParam = errors.New("missing parameter")
+	ErrSpecVersion  = errors.New("unsupported spec version")
+)
+
+// LikelihoodParams returns the names of the parameters of a likelihood
+// family in the order they appear in Params, or nil for an unknown family
+func LikelihoodParams(name string) []string {
+	if e := likelihoodFamily(canonicalName(name)); e != nil {
+		return append([]string(nil), e.params...)
+	}
+	return nil
+}
+
+// PriorParams returns the names of the parameters of a prior family in the
+// order they appear in Params, or nil for an unknown family. A missing
+// "min" or "max" parameter defaults to -Inf or Inf.
+func PriorParams(name string) []string {
+	if e := priorFamily(canonicalName(name)); e != nil {
+		return append([]string(nil), e.params...)
+	}
+	return nil
+}
+
+// ModelSpec is a complete model specification. It is encoded as
+//
+//	{
+//	  "version": 1,
+//	  "likelihoodDef": {"distribution": "noncentral_d", "parameters": {"d": 0.5, "n": 20}},
+//	  "altpriorDef": {"distribution": "cauchy", "parameters": {"location": 0, "scale": 0.707}},
+//	  "nullpriorDef": {"distribution": "point", "parameters": {"point": 0}}
+//	}
+//
+// which is the same layout as the object passed to the computeAll WASM
+// function.
+type ModelSpec struct {
+	Version    int                  `json:"version"`
+	Likelihood LikelihoodDefinition `json:"likelihoodDef"`
+	AltPrior   PriorDefinition      `json:"altpriorDef"`
+	NullPrior  PriorDefinition      `json:"nullpriorDef"`
+}
+
+// definitionJSON is the JSON form of a likelihood or prior definition
+type definitionJSON struct {
+	Distribution string              `json:"distribution"`
+	Parameters   map[string]*float64 `json:"parameters"`
+}
+
+// NewModelSpec returns a ModelSpec with the current version
+func NewModelSpec(likelihood LikelihoodDefinition, altprior PriorDefinition, nullprior PriorDefinition) ModelSpec {
+	return ModelSpec{
+		Version:    SpecVersion,
+		Likelihood: likelihood,
+		AltPrior:   altprior,
+		NullPrior:  nullprior,
+	}
+}
+
+// ParseModelSpec decodes and validates a JSON model specification. A
+// missing version is treated as the current version.
+func ParseModelSpec(data []byte) (ModelSpec, error) {
+
+	var spec ModelSpec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return spec, err
+	}
+	if err := spec.Validate(); err != nil {
+		return spec, err
+	}
+	return spec, nil
+}
+
+// Validate checks the version and each of the definitions in the spec
+func (m ModelSpec) Validate() error {
+
+	if m.Version < 0 || m.Version > SpecVersion {
+		return fmt.Errorf("%w: %d", ErrSpecVersion, m.Version)
+	}
+	if err := validateLikelihood(m.Likelihood); err != nil {
+		return err
+	}
+	if err := validatePrior(m.AltPrior); err != nil {
+		return err
+	}
+	return validatePrior(m.NullPrior)
+}
+
+// UnmarshalJSON decodes a model spec. A missing version is the current
+// version. Missing prior bounds are left infinite; Pp restricts the prior
+// to the support of the likelihood (see SupportPrior).
+func (m *ModelSpec) UnmarshalJSON(data []byte) error {
+
+	type plain ModelSpec
+	spec := plain{Version: SpecVersion}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return err
+	}
+
+	*m = ModelSpec(spec)
+	return nil
+}
+
+// Finite returns a pointer to x, or nil if x is infinite or NaN, so that
+// values that can't be represented in JSON are encoded as null
+func Finite(x float64) *float64 {
+	if math.IsInf(x, 0) || math.IsNaN(x) {
+		return nil
+	}
+	return &x
+}
+
+// canonicalName maps names used by the web app (e.g. "student t") to the
+// names used by the package (e.g. "student_t")
+func canonicalName(name string) string {
+	return strings.ReplaceAll(strings.TrimSpace(name), " ", "_")
+}
+
+func encodeDefinition(kind string, name string, params []float64, family *entry) ([]byte, error) {
+
+	if family == nil {
+		return nil, &ModelError{Kind: kind, Family: name, Err: ErrUnknownFamily}
+	}
+	names := family.params
+	if family.bounded() && len(params) == len(names)-2 {
+		names = names[:len(params)]
+	}
+	if len(params) != len(names) {
+		return nil, &ModelError{Kind: kind, Family: name, Err: fmt.Errorf("%w: got %d, want %d", ErrParamCount, len(params), len(names))}
+	}
+
+	out := definitionJSON{Distribution: name, Parameters: map[string]*float64{}}
+	for i, param := range names {
+		value := params[i]
+		// infinite bounds are the default and can't be represented in JSON
+		if (param == "min" && math.IsInf(value, -1)) || (param == "max" && math.IsInf(value, 1)) {
+			continue
+		}
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			return nil, &ModelError{Kind: kind, Family: name, Param: param, Err: ErrNotFinite}
+		}
+		out.Parameters[param] = &value
+	}
+
+	return json.Marshal(out)
+}
+
+func decodeDefinition(kind string, data []byte, lookup func(name string) *entry) (string, []float64, error) {
+
+	var in definitionJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return "", nil, err
+	}
+
+	name := canonicalName(in.Distribution)
+	family := lookup(name)
+	if family == nil {
+		return name, nil, &ModelError{Kind: kind, Family: name, Err: ErrUnknownFamily}
+	}
+	names := family.params
+
+	params := make([]float64, len(names))
+	for i, param := range names {
+		value := in.Parameters[param]
+		switch {
+		case value != nil:
+			params[i] = *value
+		case param == "min":
+			params[i] = math.Inf(-1)
+		case param == "max":
+			params[i] = math.Inf(1)
+		default:
+			return name, nil, &ModelError{Kind: kind, Family: name, Param: param, Err: ErrMissingParam}
+		}
+	}
+
+	// leave out default bounds, which are optional
+	n := len(params)
+	if family.bounded() && math.IsInf(params[n-2], -1) && math.IsInf(params[n-1], 1) {
+		params = params[:n-2]
+	}
+
+	return name, params, nil
+}
+
+// MarshalJSON encodes the likelihood definition with named parameters
+func (l LikelihoodDefinition) MarshalJSON() ([]byte, error) {
+	return encodeDefinition("likelihood", l.Name, l.Params, likelihoodFamily(l.Name))
+}
+
+// UnmarshalJSON decodes a likelihood definition with named parameters
+func (l *LikelihoodDefinition) UnmarshalJSON(data []byte) error {
+	name, params, err := decodeDefinition("likelihood", data, likelihoodFamily)
+	if err != nil {
+		return err
+	}
+	l.Name = name
+	l.Params = params
+	return nil
+}
+
+// MarshalJSON encodes the prior definition with named parameters
+func (p PriorDefinition) MarshalJSON() ([]byte, error) {
+	return encodeDefinition("prior", p.Name, p.Params, priorFamily(p.Name))
+}
+
+// UnmarshalJSON decodes a prior definition with named parameters
+func (p *PriorDefinition) UnmarshalJSON(data []byte) error {
+	name, params, err := decodeDefinition("prior", data, priorFamily)
+	if err != nil {
+		return err
+	}
+	p.Name = name
+	p.Params = params
+	return nil
+}
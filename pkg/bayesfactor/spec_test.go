@@ -0,0 +1,194 @@
+package bayesfactor
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSpecRoundTrip(t *testing.T) {
+
+	inf := math.Inf(1)
+	specs := []ModelSpec{
+		NewModelSpec(
+			LikelihoodDefinition{Name: "noncentral_d", Params: []float64{0.5, 20}},
+			PriorDefinition{Name: "cauchy", Params: []float64{0, 0.707}},
+			PriorDefinition{Name: "point", Params: []float64{0}},
+		),
+		NewModelSpec(
+			LikelihoodDefinition{Name: "normal", Params: []float64{5.5, 32.35}},
+			PriorDefinition{Name: "normal", Params: []float64{0, 13.3, 0, inf}},
+			PriorDefinition{Name: "uniform", Params: []float64{-1, 1}},
+		),
+		NewModelSpec(
+			LikelihoodDefinition{Name: "student_t", Params: []float64{5.47, 32.2, 119}},
+			PriorDefinition{Name: "student_t", Params: []float64{13.3, 4.93, 72, -inf, 0}},
+			PriorDefinition{Name: "point", Params: []float64{0}},
+		),
+		NewModelSpec(
+			LikelihoodDefinition{Name: "binomial", Params: []float64{8, 11}},
+			PriorDefinition{Name: "beta", Params: []float64{2.5, 1}},
+			PriorDefinition{Name: "point", Params: []float64{0.5}},
+		),
+		NewModelSpec(
+			LikelihoodDefinition{Name: "binomial", Params: []float64{8, 11}},
+			PriorDefinition{Name: "beta", Params: []float64{2.5, 1, 0.5, 1}},
+			PriorDefinition{Name: "uniform", Params: []float64{0, 1, 0, 0.5}},
+		),
+		NewModelSpec(
+			LikelihoodDefinition{Name: "correlation", Params: []float64{0.3, 50}},
+			PriorDefinition{Name: "stretched_beta", Params: []float64{2, 2, 0, 1}},
+			PriorDefinition{Name: "point", Params: []float64{0}},
+		),
+		NewModelSpec(
+			LikelihoodDefinition{Name: "binomial2", Params: []float64{10, 50, 18, 50}},
+			PriorDefinition{Name: "normal", Params: []float64{0, 1, 0, inf}},
+			PriorDefinition{Name: "point", Params: []float64{0}},
+		),
+		NewModelSpec(
+			LikelihoodDefinition{Name: "binomial2_diff", Params: []float64{10, 50, 18, 50}},
+			PriorDefinition{Name: "uniform", Params: []float64{-1, 1}},
+			PriorDefinition{Name: "point", Params: []float64{0}},
+		),
+		NewModelSpec(
+			LikelihoodDefinition{Name: "poisson", Params: []float64{12, 4.5}},
+			PriorDefinition{Name: "gamma", Params: []float64{2, 1}},
+			PriorDefinition{Name: "point", Params: []float64{2}},
+		),
+		NewModelSpec(
+			LikelihoodDefinition{Name: "negative_binomial", Params: []float64{12, 3, 4.5}},
+			PriorDefinition{Name: "lognormal", Params: []float64{0, 1, 0, 5}},
+			PriorDefinition{Name: "gamma", Params: []float64{20, 10, 1, inf}},
+		),
+		NewModelSpec(
+			LikelihoodDefinition{Name: "noncentral_d2", Params: []float64{-0.64, 15, 16}},
+			PriorDefinition{Name: "normal", Params: []float64{0, 1}},
+			PriorDefinition{Name: "point", Params: []float64{0}},
+		),
+		NewModelSpec(
+			LikelihoodDefinition{Name: "noncentral_t", Params: []float64{2.03, 79}},
+			PriorDefinition{Name: "cauchy", Params: []float64{0, 1}},
+			PriorDefinition{Name: "point", Params: []float64{0}},
+		),
+	}
+
+	for _, spec := range specs {
+		data, err := json.Marshal(spec)
+		if err != nil {
+			t.Fatalf("%s: got error %v", spec.Likelihood.Name, err)
+		}
+		got, err := ParseModelSpec(data)
+		if err != nil {
+			t.Fatalf("%s: got error %v", data, err)
+		}
+		if !cmp.Equal(got, spec) {
+			t.Fatalf("got %v, wanted %v", got, spec)
+		}
+	}
+}
+
+func TestSpecDecode(t *testing.T) {
+
+	// the layout used by the web app, without a version
+	data := []byte(`{
+		"likelihoodDef": {"distribution": "noncentral d", "parameters": {"d": 0.5, "n": 20}},
+		"altpriorDef": {"distribution": "cauchy", "parameters": {"location": 0, "scale": 0.707, "min": 0, "max": null}},
+		"nullpriorDef": {"distribution": "point", "parameters": {"point": 0}}
+	}`)
+
+	got, err := ParseModelSpec(data)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	want := NewModelSpec(
+		LikelihoodDefinition{Name: "noncentral_d", Params: []float64{0.5, 20}},
+		PriorDefinition{Name: "cauchy", Params: []float64{0, 0.707, 0, math.Inf(1)}},
+		PriorDefinition{Name: "point", Params: []float64{0}},
+	)
+	if !cmp.Equal(got, want) {
+		t.Fatalf("got %v, wanted %v", got, want)
+	}
+
+	// missing prior bounds are left out, and restricted to the support of
+	// the likelihood when the marginal likelihood is computed
+	data = []byte(`{
+		"version": 1,
+		"likelihoodDef": {"distribution": "binomial", "parameters": {"successes": 2, "trials": 10}},
+		"altpriorDef": {"distribution": "normal", "parameters": {"mean": 0, "sd": 1}},
+		"nullpriorDef": {"distribution": "point", "parameters": {"point": 0.5}}
+	}`)
+	got, err = ParseModelSpec(data)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	if !cmp.Equal(got.AltPrior.Params, []float64{0, 1}) {
+		t.Fatalf("got %v, wanted [0 1]", got.AltPrior.Params)
+	}
+	bf, _ := Bayesfactor(got.Likelihood, got.AltPrior, got.NullPrior)
+	bounded, _ := Bayesfactor(got.Likelihood, PriorDefinition{Name: "normal", Params: []float64{0, 1, 0, 1}}, got.NullPrior)
+	compare(t, bf, bounded)
+}
+
+func TestSpecRoundTripBytes(t *testing.T) {
+
+	// a prior with infinite bounds under a likelihood with bounded support
+	// is encoded the same way after decoding
+	spec := NewModelSpec(
+		LikelihoodDefinition{Name: "binomial", Params: []float64{2, 10}},
+		PriorDefinition{Name: "normal", Params: []float64{0, 1, math.Inf(-1), math.Inf(1)}},
+		PriorDefinition{Name: "point", Params: []float64{0.5}},
+	)
+	data, err := json.Marshal(spec)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	decoded, err := ParseModelSpec(data)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	again, err := json.Marshal(decoded)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	if string(again) != string(data) {
+		t.Fatalf("got %s, wanted %s", again, data)
+	}
+}
+
+func TestSpecErrors(t *testing.T) {
+
+	cases := []struct {
+		name string
+		data string
+		want error
+	}{
+		{"missing parameter", `{"likelihoodDef": {"distribution": "normal", "parameters": {"mean": 1}},
+			"altpriorDef": {"distribution": "point", "parameters": {"point": 0}},
+			"nullpriorDef": {"distribution": "point", "parameters": {"point": 0}}}`, ErrMissingParam},
+		{"unknown family", `{"likelihoodDef": {"distribution": "normal", "parameters": {"mean": 1, "sd": 1}},
+			"altpriorDef": {"distribution": "weibull", "parameters": {"shape": 1}},
+			"nullpriorDef": {"distribution": "point", "parameters": {"point": 0}}}`, ErrUnknownFamily},
+		{"future version", `{"version": 99,
+			"likelihoodDef": {"distribution": "normal", "parameters": {"mean": 1, "sd": 1}},
+			"altpriorDef": {"distribution": "point", "parameters": {"point": 1}},
+			"nullpriorDef": {"distribution": "point", "parameters": {"point": 0}}}`, ErrSpecVersion},
+		{"invalid value", `{"likelihoodDef": {"distribution": "normal", "parameters": {"mean": 1, "sd": 0}},
+			"altpriorDef": {"distribution": "point", "parameters": {"point": 1}},
+			"nullpriorDef": {"distribution": "point", "parameters": {"point": 0}}}`, ErrNonPositiveSD},
+	}
+
+	for _, c := range cases {
+		if _, err := ParseModelSpec([]byte(c.data)); !errors.Is(err, c.want) {
+			t.Fatalf("%s: got error %v, wanted %v", c.name, err, c.want)
+		}
+	}
+
+	// NaN can't be encoded
+	likelihood := LikelihoodDefinition{Name: "normal", Params: []float64{math.NaN(), 1}}
+	if _, err := json.Marshal(likelihood); !errors.Is(err, ErrNotFinite) {
+		t.Fatalf("got error %v, wanted %v", err, ErrNotFinite)
+	}
+}
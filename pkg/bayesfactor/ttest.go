@@ -0,0 +1,188 @@
+package bayesfactor
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Errors returned by the t-tests when the samples can't be summarised
+var (
+	ErrTooFewObservations = errors.New("too few observations")
+	ErrNoVariance         = errors.New("observations have no variance")
+	ErrPairedLength       = errors.New("paired samples have different lengths")
+	ErrInvalidObservation = errors.New("observations must be finite")
+)
+
+// JZSScale is the scale of the default (JZS) cauchy prior on the
+// standardized effect size used by the t-tests
+const JZSScale = math.Sqrt2 / 2
+
+// TTestSummary holds the summary statistics of a t-test. The one-sample
+// and paired tests only set the first sample, which for the paired test
+// holds the differences.
+type TTestSummary struct {
+	N1       int     `json:"n1"`
+	Mean1    float64 `json:"mean1"`
+	SD1      float64 `json:"sd1"`
+	N2       int     `json:"n2,omitempty"`
+	Mean2    float64 `json:"mean2,omitempty"`
+	SD2      float64 `json:"sd2,omitempty"`
+	MeanDiff float64 `json:"meanDiff"`
+	SE       float64 `json:"se"`
+	T        float64 `json:"t"`
+	DF       float64 `json:"df"`
+	D        float64 `json:"d"`
+}
+
+// TTest is the result of a t-test: the model, with the default cauchy
+// prior on the effect size as the alternative and a point null at no
+// effect, the summary statistics of the samples, and the Bayes factor
+// (BF10). The model can be passed on to Pp, Posterior or the analysis
+// package.
+type TTest struct {
+	Spec    ModelSpec    `json:"spec"`
+	Summary TTestSummary `json:"summary"`
+	Bf      float64      `json:"bf"`
+}
+
+// OneSampleTTest tests whether the mean of x differs from mu, with a
+// noncentral_d likelihood of Cohen's d = (mean - mu) / sd
+func OneSampleTTest(x []float64, mu float64) (TTest, error) {
+
+	n, mean, sd, err := describe(x, 2)
+	if err != nil {
+		return TTest{}, err
+	}
+
+	var summary TTestSummary
+	summary.N1, summary.Mean1, summary.SD1 = n, mean, sd
+	summary.MeanDiff = mean - mu
+	summary.SE = sd / math.Sqrt(float64(n))
+	summary.T = summary.MeanDiff / summary.SE
+	summary.DF = float64(n - 1)
+	summary.D = summary.MeanDiff / sd
+
+	likelihood := LikelihoodDefinition{Name: "noncentral_d", Params: []float64{summary.D, float64(n)}}
+	return jzs(likelihood, JZSScale, summary)
+}
+
+// PairedTTest tests whether the mean of the differences x - y differs from
+// 0, as a one-sample test of the differences
+func PairedTTest(x []float64, y []float64) (TTest, error) {
+
+	if len(x) != len(y) {
+		return TTest{}, fmt.Errorf("%w: %d and %d", ErrPairedLength, len(x), len(y))
+	}
+	differences := make([]float64, len(x))
+	for i := range x {
+		differences[i] = x[i] - y[i]
+	}
+	return OneSampleTTest(differences, 0)
+}
+
+// TwoSampleTTest tests whether the means of the independent samples x and
+// y differ. The effect size is the difference x - y over the pooled sd,
+// with a noncentral_d2 likelihood. With welch the variances aren't
+// assumed to be equal: the effect size is standardized by the root mean
+// square of the two sds, and the likelihood is a noncentral_t likelihood
+// with the Welch-Satterthwaite df, whose noncentrality parameter is the
+// effect size divided by the scale of the t statistic, so the default
+// prior is scaled by the same amount.
+func TwoSampleTTest(x []float64, y []float64, welch bool) (TTest, error) {
+
+	minimum := 1
+	if welch {
+		minimum = 2
+	}
+	n1, mean1, sd1, err := describe(x, minimum)
+	if err != nil {
+		return TTest{}, err
+	}
+	n2, mean2, sd2, err := describe(y, minimum)
+	if err != nil {
+		return TTest{}, err
+	}
+	if n1+n2 < 3 {
+		return TTest{}, fmt.Errorf("%w: %d", ErrTooFewObservations, n1+n2)
+	}
+
+	var summary TTestSummary
+	summary.N1, summary.Mean1, summary.SD1 = n1, mean1, sd1
+	summary.N2, summary.Mean2, summary.SD2 = n2, mean2, sd2
+	summary.MeanDiff = mean1 - mean2
+	v1, v2 := sd1*sd1/float64(n1), sd2*sd2/float64(n2)
+
+	if welch {
+		summary.SE = math.Sqrt(v1 + v2)
+		summary.DF = (v1 + v2) * (v1 + v2) / (v1*v1/float64(n1-1) + v2*v2/float64(n2-1))
+		sd := math.Sqrt((sd1*sd1 + sd2*sd2) / 2)
+		if !(summary.SE > 0) {
+			return TTest{}, ErrNoVariance
+		}
+		summary.T = summary.MeanDiff / summary.SE
+		summary.D = summary.MeanDiff / sd
+
+		likelihood := LikelihoodDefinition{Name: "noncentral_t", Params: []float64{summary.T, summary.DF}}
+		return jzs(likelihood, JZSScale*sd/summary.SE, summary)
+	}
+
+	df := float64(n1 + n2 - 2)
+	sd := math.Sqrt((float64(n1-1)*sd1*sd1 + float64(n2-1)*sd2*sd2) / df)
+	if !(sd > 0) {
+		return TTest{}, ErrNoVariance
+	}
+	summary.SE = sd * math.Sqrt(1/float64(n1)+1/float64(n2))
+	summary.T = summary.MeanDiff / summary.SE
+	summary.DF = df
+	summary.D = summary.MeanDiff / sd
+
+	likelihood := LikelihoodDefinition{Name: "noncentral_d2", Params: []float64{summary.D, float64(n1), float64(n2)}}
+	return jzs(likelihood, JZSScale, summary)
+}
+
+// jzs computes the Bayes factor for a t-test with a cauchy prior with the
+// given scale on the effect and a point null at 0
+func jzs(likelihood LikelihoodDefinition, scale float64, summary TTestSummary) (TTest, error) {
+
+	spec := NewModelSpec(
+		likelihood,
+		PriorDefinition{Name: "cauchy", Params: []float64{0, scale, math.Inf(-1), math.Inf(1)}},
+		PriorDefinition{Name: "point", Params: []float64{0}},
+	)
+	bf, err := Bayesfactor(spec.Likelihood, spec.AltPrior, spec.NullPrior)
+	return TTest{Spec: spec, Summary: summary, Bf: bf}, err
+}
+
+// describe returns the number of observations, mean and sd of x, which
+// needs at least minimum observations. A single observation has an sd of
+// 0.
+func describe(x []float64, minimum int) (int, float64, float64, error) {
+
+	n := len(x)
+	if n < minimum {
+		return n, math.NaN(), math.NaN(), fmt.Errorf("%w: got %d, want at least %d", ErrTooFewObservations, n, minimum)
+	}
+
+	var mean float64
+	for i, value := range x {
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			return n, math.NaN(), math.NaN(), fmt.Errorf("%w: observation %d is %v", ErrInvalidObservation, i, value)
+		}
+		mean += value
+	}
+	mean /= float64(n)
+	if n == 1 {
+		return n, mean, 0, nil
+	}
+
+	var ss float64
+	for _, value := range x {
+		ss += (value - mean) * (value - mean)
+	}
+	sd := math.Sqrt(ss / float64(n-1))
+	if minimum >= 2 && !(sd > 0) {
+		return n, mean, sd, ErrNoVariance
+	}
+	return n, mean, sd, nil
+}
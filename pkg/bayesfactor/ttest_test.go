@@ -0,0 +1,93 @@
+package bayesfactor
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+// the sleep data from R
+var (
+	sleep1 = []float64{0.7, -1.6, -0.2, -1.2, -0.1, 3.4, 3.7, 0.8, 0.0, 2.0}
+	sleep2 = []float64{1.9, 0.8, 1.1, 0.1, -0.1, 4.4, 5.5, 1.6, 4.6, 3.4}
+)
+
+func TestTTest(t *testing.T) {
+
+	// reference values are from BayesFactor::ttestBF and t.test
+	paired, err := PairedTTest(sleep1, sleep2)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	compare(t, paired.Bf, 17.25888)
+	compare(t, paired.Summary.T, -4.062128)
+	compare(t, paired.Summary.MeanDiff, -1.58)
+	if paired.Summary.N1 != 10 || paired.Summary.DF != 9 || paired.Spec.Likelihood.Name != "noncentral_d" {
+		t.Fatalf("got %+v", paired)
+	}
+
+	independent, err := TwoSampleTTest(sleep1, sleep2, false)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	compare(t, independent.Bf, 1.265925)
+	compare(t, independent.Summary.T, -1.860813)
+	if independent.Summary.DF != 18 || independent.Spec.Likelihood.Name != "noncentral_d2" {
+		t.Fatalf("got %+v", independent)
+	}
+
+	welch, err := TwoSampleTTest(sleep1, sleep2, true)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	compare(t, welch.Summary.T, -1.860813)
+	compare(t, welch.Summary.DF, 17.77647)
+	compare(t, welch.Summary.D, independent.Summary.D)
+	if welch.Spec.Likelihood.Name != "noncentral_t" || !(welch.Bf > 0) {
+		t.Fatalf("got %+v", welch)
+	}
+
+	// with equal sample sizes and sds the welch test is the pooled test
+	x := []float64{1, 2, 3, 4, 5}
+	y := []float64{3, 4, 5, 6, 7}
+	pooled, _ := TwoSampleTTest(x, y, false)
+	welch, _ = TwoSampleTTest(x, y, true)
+	compare(t, welch.Bf, pooled.Bf)
+
+	// the one-sample test of the differences is the paired test
+	differences := make([]float64, len(sleep1))
+	for i := range sleep1 {
+		differences[i] = sleep2[i] - sleep1[i]
+	}
+	oneSample, _ := OneSampleTTest(differences, 0)
+	compare(t, oneSample.Bf, paired.Bf)
+	shifted, _ := OneSampleTTest(sleep2, 1.58)
+	compare(t, shifted.Summary.MeanDiff, 0.75)
+}
+
+func TestTTestErrors(t *testing.T) {
+
+	cases := []struct {
+		name string
+		run  func() (TTest, error)
+		want error
+	}{
+		{"paired length", func() (TTest, error) { return PairedTTest(sleep1, sleep2[:9]) }, ErrPairedLength},
+		{"one observation", func() (TTest, error) { return OneSampleTTest([]float64{1}, 0) }, ErrTooFewObservations},
+		{"no observations", func() (TTest, error) { return TwoSampleTTest(nil, sleep2, false) }, ErrTooFewObservations},
+		{"welch one observation", func() (TTest, error) { return TwoSampleTTest([]float64{1}, sleep2, true) }, ErrTooFewObservations},
+		{"constant", func() (TTest, error) { return OneSampleTTest([]float64{2, 2, 2}, 0) }, ErrNoVariance},
+		{"pooled constant", func() (TTest, error) { return TwoSampleTTest([]float64{1, 1}, []float64{2, 2}, false) }, ErrNoVariance},
+		{"nan", func() (TTest, error) { return OneSampleTTest([]float64{1, math.NaN(), 3}, 0) }, ErrInvalidObservation},
+	}
+	for _, c := range cases {
+		if _, err := c.run(); !errors.Is(err, c.want) {
+			t.Fatalf("%s: got error %v, wanted %v", c.name, err, c.want)
+		}
+	}
+
+	// a single observation in one group is enough for the pooled test
+	if _, err := TwoSampleTTest([]float64{1}, sleep2, false); err != nil {
+		t.Fatalf("got error %v", err)
+	}
+}
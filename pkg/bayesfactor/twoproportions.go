@@ -0,0 +1,199 @@
+package bayesfactor
+
+import (
+	"math"
+
+	. "pkg/distributions"
+)
+
+// Two proportion likelihoods compare the successes out of the trials in two
+// independent groups. The parameter is the difference between group 2 and
+// group 1, either as a log odds ratio (binomial2) or as a difference in
+// proportions (binomial2_diff), and the remaining nuisance parameter is
+// integrated out so that any prior on the difference can be used:
+//
+//   - for the log odds ratio psi, the proportions are
+//     logistic(beta - psi / 2) and logistic(beta + psi / 2), where the
+//     grand mean beta has a standard normal prior
+//   - for the difference delta, the proportion in group 1 has a uniform
+//     prior on the values that keep both proportions within [0, 1]
+//
+// Under both models the likelihood at no difference is the likelihood of a
+// common proportion, so a point null at 0 tests "no difference", and a
+// prior truncated to [0, Inf) or (-Inf, 0] gives a directional alternative.
+
+// nuisanceSD is the sd of the normal prior on the grand mean log odds of
+// the log odds ratio model
+const nuisanceSD = 1
+
+// nuisanceNodes is the number of Gauss-Hermite nodes used to integrate out
+// the nuisance parameter
+const nuisanceNodes = 64
+
+// two proportion likelihood of a log odds ratio
+
+func Binomial2Likelihood(successes1 float64, trials1 float64, successes2 float64, trials2 float64) func(x float64) float64 {
+	return exponentiate(logBinomial2(successes1, trials1, successes2, trials2))
+}
+
+// two proportion likelihood of a difference in proportions
+
+func Binomial2DiffLikelihood(successes1 float64, trials1 float64, successes2 float64, trials2 float64) func(x float64) float64 {
+	return exponentiate(logBinomial2Diff(successes1, trials1, successes2, trials2))
+}
+
+// logBinomial2 returns the log likelihood of the log odds ratio psi, with
+// the grand mean log odds beta integrated out
+func logBinomial2(y1 float64, n1 float64, y2 float64, n2 float64) func(psi float64) float64 {
+
+	// the binomial coefficients don't depend on the parameters
+	constant := lchoose(n1, y1) + lchoose(n2, y2)
+	start := logit((y1 + y2 + 0.5) / (n1 + n2 + 1))
+
+	return func(psi float64) float64 {
+		if math.IsInf(psi, 0) || math.IsNaN(psi) {
+			return math.Inf(-1)
+		}
+
+		logf := func(beta float64) float64 {
+			a, b := beta-psi/2, beta+psi/2
+			return y1*logSigmoid(a) + (n1-y1)*logSigmoid(-a) +
+				y2*logSigmoid(b) + (n2-y2)*logSigmoid(-b) +
+				LogDnorm(beta, 0, nuisanceSD)
+		}
+		derivatives := func(beta float64) (float64, float64) {
+			p1, p2 := sigmoid(beta-psi/2), sigmoid(beta+psi/2)
+			grad := y1 - n1*p1 + y2 - n2*p2 - beta/(nuisanceSD*nuisanceSD)
+			hess := -n1*p1*(1-p1) - n2*p2*(1-p2) - 1/(nuisanceSD*nuisanceSD)
+			return grad, hess
+		}
+
+		mode, scale := findMode(derivatives, start)
+		return constant + IntegrateHermite(logf, mode, scale, nuisanceNodes)
+	}
+}
+
+// logBinomial2Diff returns the log likelihood of the difference in
+// proportions delta = p2 - p1, with p1 integrated out. p1 is uniform on
+// [lo, lo + w], where lo = max(0, -delta) and w = 1 - |delta|, and is
+// integrated on the logit scale with p1 = lo + w * logistic(u), so the
+// density of p1 cancels with the jacobian apart from
+// logistic(u) * logistic(-u).
+func logBinomial2Diff(y1 float64, n1 float64, y2 float64, n2 float64) func(delta float64) float64 {
+
+	constant := lchoose(n1, y1) + lchoose(n2, y2)
+
+	return func(delta float64) float64 {
+		if !(delta > -1 && delta < 1) {
+			return math.Inf(-1)
+		}
+
+		// p1, 1 - p1, p2 and 1 - p2 are computed from their distance to the
+		// ends of the interval to keep their precision near 0
+		below, above := math.Max(0, -delta), math.Max(0, delta)
+		w := 1 - math.Abs(delta)
+		proportions := func(u float64) (float64, float64, float64, float64) {
+			s, t := w*sigmoid(u), w*sigmoid(-u)
+			return below + s, above + t, above + s, below + t
+		}
+
+		logf := func(u float64) float64 {
+			p1, q1, p2, q2 := proportions(u)
+			return xlogy(y1, p1) + xlogy(n1-y1, q1) +
+				xlogy(y2, p2) + xlogy(n2-y2, q2) +
+				logSigmoid(u) + logSigmoid(-u)
+		}
+		derivatives := func(u float64) (float64, float64) {
+			p1, q1, p2, q2 := proportions(u)
+			s := sigmoid(u)
+			dp := w * s * (1 - s)
+			a := ratio(y1, p1) - ratio(n1-y1, q1) + ratio(y2, p2) - ratio(n2-y2, q2)
+			b := -ratio(y1, p1*p1) - ratio(n1-y1, q1*q1) - ratio(y2, p2*p2) - ratio(n2-y2, q2*q2)
+			grad := a*dp + 1 - 2*s
+			hess := b*dp*dp + a*dp*(1-2*s) - 2*s*(1-s)
+			return grad, hess
+		}
+
+		// start from the pooled proportion, if it's within the interval
+		start := 0.0
+		if f := ((y1+y2+0.5)/(n1+n2+1) - below) / w; f > 0 && f < 1 {
+			start = logit(f)
+		}
+		mode, scale := findMode(derivatives, start)
+		return constant + IntegrateHermite(logf, mode, scale, nuisanceNodes)
+	}
+}
+
+// findMode finds the mode of a unimodal log density from its first and
+// second derivatives with Newton's method, starting at start, and returns
+// the mode and the scale of the Laplace approximation
+func findMode(derivatives func(x float64) (float64, float64), start float64) (float64, float64) {
+
+	x := start
+	for i := 0; i < 100; i++ {
+		grad, hess := derivatives(x)
+		var step float64
+		if hess < 0 {
+			step = -grad / hess
+		} else {
+			step = math.Copysign(1, grad)
+		}
+		step = math.Max(-2, math.Min(2, step))
+		x += step
+		if math.Abs(step) < 1e-10*(1+math.Abs(x)) {
+			break
+		}
+	}
+
+	_, hess := derivatives(x)
+	if !(hess < 0) {
+		return x, 1
+	}
+	return x, 1 / math.Sqrt(-hess)
+}
+
+// binomial2Estimate returns the empirical log odds ratio, with 0.5 added to
+// each cell, and its standard error
+func binomial2Estimate(y1 float64, n1 float64, y2 float64, n2 float64) (float64, float64) {
+	a, b, c, d := y1+0.5, n1-y1+0.5, y2+0.5, n2-y2+0.5
+	return math.Log(c * b / (a * d)), math.Sqrt(1/a + 1/b + 1/c + 1/d)
+}
+
+// binomial2DiffEstimate returns the difference in the proportions, each
+// with one success and one failure added, and its standard error
+func binomial2DiffEstimate(y1 float64, n1 float64, y2 float64, n2 float64) (float64, float64) {
+	p1, p2 := (y1+1)/(n1+2), (y2+1)/(n2+2)
+	return p2 - p1, math.Sqrt(p1*(1-p1)/(n1+2) + p2*(1-p2)/(n2+2))
+}
+
+func sigmoid(x float64) float64 {
+	return 1 / (1 + math.Exp(-x))
+}
+
+// logSigmoid is log(sigmoid(x)) without underflow for large negative x
+func logSigmoid(x float64) float64 {
+	if x < -30 {
+		return x
+	}
+	return -math.Log1p(math.Exp(-x))
+}
+
+func logit(p float64) float64 {
+	return math.Log(p / (1 - p))
+}
+
+// xlogy is x * log(y), with 0 * log(0) = 0
+func xlogy(x float64, y float64) float64 {
+	if x == 0 {
+		return 0
+	}
+	return x * math.Log(y)
+}
+
+// ratio is x / y, with 0 / 0 = 0
+func ratio(x float64, y float64) float64 {
+	if x == 0 {
+		return 0
+	}
+	return x / y
+}
@@ -0,0 +1,63 @@
+package bayesfactor
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestTwoProportions(t *testing.T) {
+
+	inf := math.Inf(1)
+	point := PriorDefinition{Name: "point", Params: []float64{0}}
+	logOdds := LikelihoodDefinition{Name: "binomial2", Params: []float64{10, 50, 18, 50}}
+	difference := LikelihoodDefinition{Name: "binomial2_diff", Params: []float64{10, 50, 18, 50}}
+
+	// with no difference the proportions have a uniform prior, so the
+	// likelihood is choose(50, 10) choose(50, 18) beta(29, 73)
+	likelihood, _ := CreateLikelihood(difference)
+	compare(t, likelihood.LogFunction(0), lchoose(50, 10)+lchoose(50, 18)+lbeta(29, 73))
+	compare(t, likelihood.LogFunction(0.16), -6.165422650367031)
+	if min, max := likelihood.Support(); min != -1 || max != 1 {
+		t.Fatalf("got support [%v, %v], wanted [-1, 1]", min, max)
+	}
+
+	likelihood, _ = CreateLikelihood(logOdds)
+	compare(t, likelihood.LogFunction(0), -7.67560115839697)
+	compare(t, likelihood.LogFunction(0.5), -6.309371439339831)
+
+	// two sided and directional alternatives
+	cases := []struct {
+		likelihood LikelihoodDefinition
+		prior      PriorDefinition
+		want       float64
+	}{
+		{logOdds, PriorDefinition{Name: "normal", Params: []float64{0, 1, -inf, inf}}, 1.5495568},
+		{logOdds, PriorDefinition{Name: "normal", Params: []float64{0, 1, 0, inf}}, 2.9407130},
+		{logOdds, PriorDefinition{Name: "uniform", Params: []float64{-1, 1}}, 1.8513941},
+		{difference, PriorDefinition{Name: "uniform", Params: []float64{-1, 1}}, 0.6266555},
+		{difference, PriorDefinition{Name: "normal", Params: []float64{0, 1, 0, 1}}, 1.3906786},
+	}
+	for _, c := range cases {
+		bf, err := Bayesfactor(c.likelihood, c.prior, point)
+		if err != nil {
+			t.Fatalf("%v: got error %v", c.likelihood.Name, err)
+		}
+		compare(t, bf, c.want)
+	}
+
+	// no successes in either group
+	for _, name := range []string{"binomial2", "binomial2_diff"} {
+		likelihood := LikelihoodDefinition{Name: name, Params: []float64{0, 400, 0, 400}}
+		bf, err := Bayesfactor(likelihood, PriorDefinition{Name: "uniform", Params: []float64{-0.5, 0.5}}, point)
+		if err != nil || !(bf > 0 && bf < 1) {
+			t.Fatalf("%v: got bf %v and error %v", name, bf, err)
+		}
+	}
+
+	_, err := CreateLikelihood(LikelihoodDefinition{Name: "binomial2", Params: []float64{10, 50, 60, 50}})
+	var modelErr *ModelError
+	if !errors.Is(err, ErrInvalidCount) || !errors.As(err, &modelErr) || modelErr.Param != "successes2" {
+		t.Fatalf("got error %v, wanted %v for successes2", err, ErrInvalidCount)
+	}
+}
@@ -0,0 +1,438 @@
+package distributions
+
+import (
+	"math"
+
+	"gonum.org/v1/gonum/mathext"
+)
+
+// Cumulative distribution (P*) and quantile (Q*) functions. These follow
+// the signatures of the R functions with the same names: lowerTail selects
+// P[X <= x] (true) or P[X > x] (false), and logP means that probabilities
+// are given or returned on the log scale. Invalid parameters give NaN.
+
+// tail returns the probability of the requested tail on the requested
+// scale given the log probabilities of both tails
+func tail(logLower float64, logUpper float64, lowerTail bool, logP bool) float64 {
+	logp := logUpper
+	if lowerTail {
+		logp = logLower
+	}
+	if logP {
+		return logp
+	}
+	return math.Exp(logp)
+}
+
+// probabilities returns the log probabilities of the lower and upper tails
+// for a probability given to a quantile function. ok is false if p isn't
+// a probability.
+func probabilities(p float64, lowerTail bool, logP bool) (logLower float64, logUpper float64, ok bool) {
+	logp := p
+	if !logP {
+		logp = math.Log(p)
+	}
+	if math.IsNaN(logp) || logp > 0 {
+		return math.NaN(), math.NaN(), false
+	}
+	if lowerTail {
+		return logp, log1mexp(logp), true
+	}
+	return log1mexp(logp), logp, true
+}
+
+// log1mexp returns log(1 - exp(x)) for x <= 0
+func log1mexp(x float64) float64 {
+	if x > -math.Ln2 {
+		return math.Log(-math.Expm1(x))
+	}
+	return math.Log1p(-math.Exp(x))
+}
+
+// invert finds x such that the nondecreasing function f(x) equals target
+// by bisection, starting from a bracket around start
+func invert(f func(float64) float64, target float64, start float64, step float64) float64 {
+
+	lo, hi := start-step, start+step
+	for i := 0; f(lo) > target; i++ {
+		if i == 1000 {
+			return math.Inf(-1)
+		}
+		step *= 2
+		lo -= step
+	}
+	for i := 0; f(hi) < target; i++ {
+		if i == 1000 {
+			return math.Inf(1)
+		}
+		step *= 2
+		hi += step
+	}
+
+	for i := 0; i < 200; i++ {
+		mid := (lo + hi) / 2
+		if mid <= lo || mid >= hi || hi-lo <= 1e-12*math.Max(1, math.Abs(mid)) {
+			break
+		}
+		if f(mid) < target {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return (lo + hi) / 2
+}
+
+// logPnormStd returns the log of the standard normal CDF, using the
+// asymptotic expansion of the Mills ratio far into the lower tail
+func logPnormStd(z float64) float64 {
+	switch {
+	case z < -30:
+		x2 := z * z
+		series := 1 - 1/x2 + 3/(x2*x2) - 15/(x2*x2*x2)
+		return -x2/2 - math.Log(-z) - math.Log(2*math.Pi)/2 + math.Log(series)
+	case z < 0:
+		return math.Log(math.Erfc(-z/math.Sqrt2) / 2)
+	}
+	return math.Log1p(-math.Erfc(z/math.Sqrt2) / 2)
+}
+
+// qnormStd returns the standard normal quantile for a log probability of
+// at most log(0.5)
+func qnormStd(logp float64) float64 {
+	if math.IsInf(logp, -1) {
+		return math.Inf(-1)
+	}
+	if logp > -700 {
+		return mathext.NormalQuantile(math.Exp(logp))
+	}
+
+	// the probability underflows so use Newton's method on the log CDF,
+	// which converges from below because the log CDF is concave
+	z := -math.Sqrt(-2 * logp)
+	for i := 0; i < 100; i++ {
+		lp := logPnormStd(z)
+		step := (lp - logp) / math.Exp(LogDnorm(z, 0, 1)-lp)
+		z -= step
+		if math.Abs(step) < 1e-14*math.Abs(z) {
+			break
+		}
+	}
+	return z
+}
+
+func Pnorm(q float64, mean float64, sd float64, lowerTail bool, logP bool) float64 {
+	z := (q - mean) / sd
+	if math.IsNaN(z) || !(sd > 0) {
+		return math.NaN()
+	}
+	return tail(logPnormStd(z), logPnormStd(-z), lowerTail, logP)
+}
+
+func Qnorm(p float64, mean float64, sd float64, lowerTail bool, logP bool) float64 {
+	logLower, logUpper, ok := probabilities(p, lowerTail, logP)
+	if !ok || !(sd > 0) || math.IsInf(mean, 0) {
+		return math.NaN()
+	}
+	if logLower <= logUpper {
+		return mean + sd*qnormStd(logLower)
+	}
+	return mean - sd*qnormStd(logUpper)
+}
+
+func Punif(q float64, min float64, max float64, lowerTail bool, logP bool) float64 {
+	if math.IsNaN(q) || !(min < max) || math.IsInf(min, 0) || math.IsInf(max, 0) {
+		return math.NaN()
+	}
+	lower := math.Max(0, math.Min(1, (q-min)/(max-min)))
+	upper := math.Max(0, math.Min(1, (max-q)/(max-min)))
+	return tail(math.Log(lower), math.Log(upper), lowerTail, logP)
+}
+
+func Qunif(p float64, min float64, max float64, lowerTail bool, logP bool) float64 {
+	logLower, logUpper, ok := probabilities(p, lowerTail, logP)
+	if !ok || !(min < max) || math.IsInf(min, 0) || math.IsInf(max, 0) {
+		return math.NaN()
+	}
+	if logLower <= logUpper {
+		return min + math.Exp(logLower)*(max-min)
+	}
+	return max - math.Exp(logUpper)*(max-min)
+}
+
+func Pcauchy(q float64, location float64, scale float64, lowerTail bool, logP bool) float64 {
+	z := (q - location) / scale
+	if math.IsNaN(z) || !(scale > 0) {
+		return math.NaN()
+	}
+	// atan2 keeps the precision of small tail probabilities
+	lower := math.Atan2(1, -z) / math.Pi
+	upper := math.Atan2(1, z) / math.Pi
+	return tail(math.Log(lower), math.Log(upper), lowerTail, logP)
+}
+
+func Qcauchy(p float64, location float64, scale float64, lowerTail bool, logP bool) float64 {
+	logLower, logUpper, ok := probabilities(p, lowerTail, logP)
+	if !ok || !(scale > 0) || math.IsInf(location, 0) {
+		return math.NaN()
+	}
+	if logLower <= logUpper {
+		return location - scale/math.Tan(math.Pi*math.Exp(logLower))
+	}
+	return location + scale/math.Tan(math.Pi*math.Exp(logUpper))
+}
+
+func Pbeta(q float64, shape1 float64, shape2 float64, lowerTail bool, logP bool) float64 {
+	if math.IsNaN(q) || !(shape1 > 0) || !(shape2 > 0) || math.IsInf(shape1, 0) || math.IsInf(shape2, 0) {
+		return math.NaN()
+	}
+	var lower, upper float64
+	switch {
+	case q <= 0:
+		lower, upper = 0, 1
+	case q >= 1:
+		lower, upper = 1, 0
+	default:
+		lower = mathext.RegIncBeta(shape1, shape2, q)
+		upper = mathext.RegIncBeta(shape2, shape1, 1-q)
+	}
+	return tail(math.Log(lower), math.Log(upper), lowerTail, logP)
+}
+
+func Qbeta(p float64, shape1 float64, shape2 float64, lowerTail bool, logP bool) float64 {
+	logLower, logUpper, ok := probabilities(p, lowerTail, logP)
+	if !ok || !(shape1 > 0) || !(shape2 > 0) || math.IsInf(shape1, 0) || math.IsInf(shape2, 0) {
+		return math.NaN()
+	}
+	if logLower <= logUpper {
+		return mathext.InvRegIncBeta(shape1, shape2, math.Exp(logLower))
+	}
+	return 1 - mathext.InvRegIncBeta(shape2, shape1, math.Exp(logUpper))
+}
+
+func Pgamma(q float64, shape float64, rate float64, lowerTail bool, logP bool) float64 {
+	if math.IsNaN(q) || !(shape > 0) || !(rate > 0) || math.IsInf(shape, 0) || math.IsInf(rate, 0) {
+		return math.NaN()
+	}
+	if q <= 0 {
+		return tail(math.Inf(-1), 0, lowerTail, logP)
+	}
+	lower := mathext.GammaIncReg(shape, rate*q)
+	upper := mathext.GammaIncRegComp(shape, rate*q)
+	return tail(math.Log(lower), math.Log(upper), lowerTail, logP)
+}
+
+func Qgamma(p float64, shape float64, rate float64, lowerTail bool, logP bool) float64 {
+	logLower, logUpper, ok := probabilities(p, lowerTail, logP)
+	if !ok || !(shape > 0) || !(rate > 0) || math.IsInf(shape, 0) || math.IsInf(rate, 0) {
+		return math.NaN()
+	}
+	if logLower <= logUpper {
+		return mathext.GammaIncRegInv(shape, math.Exp(logLower)) / rate
+	}
+	return mathext.GammaIncRegCompInv(shape, math.Exp(logUpper)) / rate
+}
+
+func Plnorm(q float64, meanlog float64, sdlog float64, lowerTail bool, logP bool) float64 {
+	if math.IsNaN(q) || !(sdlog > 0) {
+		return math.NaN()
+	}
+	if q <= 0 {
+		return tail(math.Inf(-1), 0, lowerTail, logP)
+	}
+	return Pnorm(math.Log(q), meanlog, sdlog, lowerTail, logP)
+}
+
+func Qlnorm(p float64, meanlog float64, sdlog float64, lowerTail bool, logP bool) float64 {
+	return math.Exp(Qnorm(p, meanlog, sdlog, lowerTail, logP))
+}
+
+// validBinomial checks that n is a count and p is a probability
+func validBinomial(n float64, p float64) bool {
+	return n >= 0 && n == math.Floor(n) && !math.IsInf(n, 0) && p >= 0 && p <= 1
+}
+
+func Pbinom(q float64, n float64, p float64, lowerTail bool, logP bool) float64 {
+	if math.IsNaN(q) || !validBinomial(n, p) {
+		return math.NaN()
+	}
+	// allow for representation error in q as R does
+	k := math.Floor(q + 1e-7)
+	var lower, upper float64
+	switch {
+	case k < 0:
+		lower, upper = 0, 1
+	case k >= n:
+		lower, upper = 1, 0
+	default:
+		lower = mathext.RegIncBeta(n-k, k+1, 1-p)
+		upper = mathext.RegIncBeta(k+1, n-k, p)
+	}
+	return tail(math.Log(lower), math.Log(upper), lowerTail, logP)
+}
+
+// Qbinom returns the smallest number of successes x for which
+// P[X <= x] >= p
+func Qbinom(p float64, n float64, prob float64, lowerTail bool, logP bool) float64 {
+	logLower, logUpper, ok := probabilities(p, lowerTail, logP)
+	if !ok || !validBinomial(n, prob) {
+		return math.NaN()
+	}
+
+	// compare in the smaller tail, with the same fuzz as R
+	const fuzz = 64 * 2.220446e-16
+	reached := func(x float64) bool {
+		if logLower <= logUpper {
+			return Pbinom(x, n, prob, true, true) >= logLower+math.Log1p(-fuzz)
+		}
+		return Pbinom(x, n, prob, false, true) <= logUpper+math.Log1p(fuzz)
+	}
+
+	lo, hi := -1.0, n
+	for hi-lo > 1 {
+		mid := math.Floor((lo + hi) / 2)
+		if reached(mid) {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+	return hi
+}
+
+// logPtStd returns the log CDF of the central t distribution for z <= 0
+// and the log of its complement
+func logPtStd(z float64, df float64) (float64, float64) {
+	if math.IsInf(z, -1) {
+		return math.Inf(-1), 0
+	}
+	z2 := z * z
+	small := 0.5 * mathext.RegIncBeta(df/2, 0.5, df/(df+z2))
+	large := 0.5 + 0.5*mathext.RegIncBeta(0.5, df/2, z2/(df+z2))
+	return math.Log(small), math.Log(large)
+}
+
+// qtStd returns the central t quantile for a probability of at most 0.5
+func qtStd(p float64, df float64) float64 {
+	if p == 0 {
+		return math.Inf(-1)
+	}
+	// use whichever of x and 1 - x is small to keep the precision
+	if p < 0.25 {
+		x := mathext.InvRegIncBeta(df/2, 0.5, 2*p)
+		return -math.Sqrt(df * (1 - x) / x)
+	}
+	y := mathext.InvRegIncBeta(0.5, df/2, 1-2*p)
+	return -math.Sqrt(df * y / (1 - y))
+}
+
+func Pscaled_shifted_t(q float64, mean float64, sd float64, df float64, lowerTail bool, logP bool) float64 {
+	z := (q - mean) / sd
+	if math.IsNaN(z) || !(sd > 0) || !(df > 0) {
+		return math.NaN()
+	}
+	if z <= 0 {
+		small, large := logPtStd(z, df)
+		return tail(small, large, lowerTail, logP)
+	}
+	small, large := logPtStd(-z, df)
+	return tail(large, small, lowerTail, logP)
+}
+
+func Qscaled_shifted_t(p float64, mean float64, sd float64, df float64, lowerTail bool, logP bool) float64 {
+	logLower, logUpper, ok := probabilities(p, lowerTail, logP)
+	if !ok || !(sd > 0) || !(df > 0) || math.IsInf(mean, 0) {
+		return math.NaN()
+	}
+	if logLower <= logUpper {
+		return mean + sd*qtStd(math.Exp(logLower), df)
+	}
+	return mean - sd*qtStd(math.Exp(logUpper), df)
+}
+
+// Pt is the CDF of the noncentral t distribution. With a noncentrality
+// parameter the tail that doesn't contain the bulk of the distribution is
+// found by integrating the density on the log scale.
+func Pt(q float64, df float64, ncp float64, lowerTail bool, logP bool) float64 {
+	if math.IsNaN(q) || !(df > 0) || math.IsNaN(ncp) || math.IsInf(ncp, 0) {
+		return math.NaN()
+	}
+	if ncp == 0 {
+		return Pscaled_shifted_t(q, 0, 1, df, lowerTail, logP)
+	}
+	if math.IsInf(q, 0) {
+		return Pscaled_shifted_t(q, 0, 1, df, lowerTail, logP)
+	}
+
+	logf := func(x float64) float64 { return LogDt(x, df, ncp) }
+	if q <= ncp {
+		integral, err := DefaultQuadrature.IntegrateLog(logf, math.Inf(-1), q, ncp)
+		if err == ErrIntegrand {
+			return math.NaN()
+		}
+		logLower := math.Min(integral.LogValue, 0)
+		return tail(logLower, log1mexp(logLower), lowerTail, logP)
+	}
+	integral, err := DefaultQuadrature.IntegrateLog(logf, q, math.Inf(1), ncp)
+	if err == ErrIntegrand {
+		return math.NaN()
+	}
+	logUpper := math.Min(integral.LogValue, 0)
+	return tail(log1mexp(logUpper), logUpper, lowerTail, logP)
+}
+
+// Qt is the quantile function of the noncentral t distribution. With a
+// noncentrality parameter the CDF is inverted numerically.
+func Qt(p float64, df float64, ncp float64, lowerTail bool, logP bool) float64 {
+	logLower, logUpper, ok := probabilities(p, lowerTail, logP)
+	if !ok || !(df > 0) || math.IsNaN(ncp) || math.IsInf(ncp, 0) {
+		return math.NaN()
+	}
+	if ncp == 0 {
+		return Qscaled_shifted_t(p, 0, 1, df, lowerTail, logP)
+	}
+	if math.IsInf(logLower, -1) {
+		return math.Inf(-1)
+	}
+	if math.IsInf(logUpper, -1) {
+		return math.Inf(1)
+	}
+
+	// start from the normal approximation
+	start := ncp + qnormStd(math.Min(logLower, logUpper))*math.Copysign(1, logUpper-logLower)
+	if logLower <= logUpper {
+		return invert(func(x float64) float64 { return Pt(x, df, ncp, true, true) }, logLower, start, 1)
+	}
+	return invert(func(x float64) float64 { return -Pt(x, df, ncp, false, true) }, -logUpper, start, 1)
+}
+
+// Qtruncated returns the p quantile of a distribution truncated to
+// [min, max], given P* and Q* functions with the signatures used in this
+// package. The probabilities are kept on the log scale, in the tail that
+// gives the most precision, so that bounds far into the tails can be used.
+func Qtruncated(
+	p float64,
+	cdf func(q float64, lowerTail bool, logP bool) float64,
+	quantile func(p float64, lowerTail bool, logP bool) float64,
+	min float64,
+	max float64,
+) float64 {
+
+	if !(p >= 0 && p <= 1) {
+		return math.NaN()
+	}
+	lowerTail := cdf(min, true, false) < 0.5
+	logLo, logHi := cdf(min, lowerTail, true), cdf(max, lowerTail, true)
+	if !lowerTail {
+		logLo, logHi = logHi, logLo
+		p = 1 - p
+	}
+	if !(logLo < logHi) {
+		return math.NaN()
+	}
+
+	// the probability is between the probabilities of the bounds
+	logp := logHi + math.Log(p+(1-p)*math.Exp(logLo-logHi))
+	x := quantile(logp, lowerTail, true)
+	return math.Max(min, math.Min(max, x))
+}
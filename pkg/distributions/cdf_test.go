@@ -0,0 +1,176 @@
+package distributions
+
+import (
+	"math"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCDF(t *testing.T) {
+
+	const tolerance = .0001
+	opt := cmp.Comparer(func(x, y float64) bool {
+		diff := math.Abs(x - y)
+		mean := math.Abs(x+y) / 2.0
+		return (diff / mean) < tolerance
+	})
+
+	// reference values are exact or from R, and for the noncentral t from
+	// high precision integration of the normal / chi mixture
+	cases := []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{"pnorm", Pnorm(1.96, 0, 1, true, false), 0.9750021},
+		{"pnorm upper", Pnorm(7, 1, 2, false, false), 0.001349898},
+		{"pnorm log", Pnorm(-40, 0, 1, true, true), -804.6084},
+		{"qnorm", Qnorm(0.975, 0, 1, true, false), 1.959964},
+		{"qnorm log", Qnorm(-804.6084420137538, 0, 1, true, true), -40},
+		{"punif", Punif(0.3, 0, 2, true, false), 0.15},
+		{"qunif upper", Qunif(0.15, 0, 2, false, false), 1.7},
+		{"pcauchy", Pcauchy(-1, 0, 1, true, false), 0.25},
+		{"pcauchy upper", Pcauchy(1e10, 0, 1, false, false), 3.183099e-11},
+		{"qcauchy", Qcauchy(0.25, 1, 2, true, false), -1},
+		{"pbeta", Pbeta(0.5, 2, 3, true, false), 0.6875},
+		{"pbeta upper", Pbeta(0.5, 2, 3, false, false), 0.3125},
+		{"qbeta", Qbeta(0.5, 1, 3, true, false), 1 - math.Pow(0.5, 1.0/3)},
+		{"pbinom", Pbinom(3, 10, 0.5, true, false), 0.171875},
+		{"pbinom log upper", Pbinom(3, 10, 0.5, false, true), math.Log(1 - 0.171875)},
+		{"qbinom", Qbinom(0.171875, 10, 0.5, true, false), 3},
+		{"qbinom above", Qbinom(0.1718751, 10, 0.5, true, false), 4},
+		{"pgamma", Pgamma(2, 3, 1.5, true, false), 0.5768099},
+		{"pgamma upper", Pgamma(2, 3, 1.5, false, false), 0.4231901},
+		{"pgamma log upper", Pgamma(50, 2, 1, false, true), -46.06817},
+		{"qgamma", Qgamma(0.5768099188731565, 3, 1.5, true, false), 2},
+		{"qgamma upper", Qgamma(0.42319008112684353, 3, 1.5, false, false), 2},
+		{"plnorm", Plnorm(2, 0, 1, true, false), 0.7558914},
+		{"qlnorm", Qlnorm(0.7558914042144173, 0, 1, true, false), 2},
+		{"pt", Pscaled_shifted_t(2, 0, 1, 10, true, false), 0.963306},
+		{"qt", Qscaled_shifted_t(0.975, 0, 1, 10, true, false), 2.228139},
+		{"pt cauchy", Pt(1, 1, 0, true, false), 0.75},
+		{"pt ncp", Pt(1, 10, 1, true, false), 0.4902401},
+		{"pt ncp upper", Pt(3, 10, 1, false, false), 0.05294914},
+		{"pt ncp log", Pt(-5, 30, 3, true, true), -26.36728},
+		{"pt ncp large", Pt(40, 1000, 38, true, false), 0.9311845},
+		{"pt ncp negative", Pt(-2, 5, -1, false, false), 0.7780747},
+	}
+	for _, c := range cases {
+		if !cmp.Equal(c.got, c.want, opt) {
+			t.Fatalf("%v: got %v, wanted %v", c.name, c.got, c.want)
+		}
+	}
+
+	// the quantile functions invert the CDFs
+	for _, x := range []float64{-30, -2.5, 0.1, 4} {
+		inverses := [][2]float64{
+			{Qnorm(Pnorm(x, 1, 2, true, false), 1, 2, true, false), x},
+			{Qnorm(Pnorm(x, 1, 2, false, true), 1, 2, false, true), x},
+			{Qcauchy(Pcauchy(x, 1, 2, false, false), 1, 2, false, false), x},
+			{Qscaled_shifted_t(Pscaled_shifted_t(x, 1, 2, 5, true, true), 1, 2, 5, true, true), x},
+			{Qt(Pt(x, 12, 2, true, true), 12, 2, true, true), x},
+			{Qt(Pt(x, 12, -1, false, false), 12, -1, false, false), x},
+		}
+		for _, pair := range inverses {
+			if !cmp.Equal(pair[0], pair[1], opt) {
+				t.Fatalf("got %v, wanted %v", pair[0], pair[1])
+			}
+		}
+	}
+	for _, x := range []float64{0.001, 0.3, 0.9} {
+		if got := Qbeta(Pbeta(x, 2.4, 2.5, false, false), 2.4, 2.5, false, false); !cmp.Equal(got, x, opt) {
+			t.Fatalf("got %v, wanted %v", got, x)
+		}
+	}
+
+	// quantiles of truncated distributions, including one far in the tail
+	pnorm := func(q float64, lowerTail bool, logP bool) float64 { return Pnorm(q, 0, 1, lowerTail, logP) }
+	qnorm := func(p float64, lowerTail bool, logP bool) float64 { return Qnorm(p, 0, 1, lowerTail, logP) }
+	truncated := [][2]float64{
+		{Qtruncated(0.75, pnorm, qnorm, math.Inf(-1), math.Inf(1)), Qnorm(0.75, 0, 1, true, false)},
+		{Qtruncated(0.5, pnorm, qnorm, 0, math.Inf(1)), Qnorm(0.75, 0, 1, true, false)},
+		{Qtruncated(0.5, pnorm, qnorm, math.Inf(-1), 0), Qnorm(0.25, 0, 1, true, false)},
+		{Qtruncated(0, pnorm, qnorm, 40, math.Inf(1)), 40},
+		// qnorm(pnorm(40, lower = FALSE) / 2, lower = FALSE)
+		{Qtruncated(0.5, pnorm, qnorm, 40, math.Inf(1)), 40.01731},
+	}
+	for _, pair := range truncated {
+		if !cmp.Equal(pair[0], pair[1], opt) {
+			t.Fatalf("got %v, wanted %v", pair[0], pair[1])
+		}
+	}
+
+	// invalid parameters and probabilities
+	for _, got := range []float64{
+		Pnorm(0, 0, -1, true, false),
+		Qnorm(1.5, 0, 1, true, false),
+		Qnorm(0.5, 0, 1, true, true),
+		Pbeta(0.5, 0, 1, true, false),
+		Pbinom(2, 10.5, 0.5, true, false),
+		Pgamma(1, 0, 1, true, false),
+		Qlnorm(0.5, 0, -1, true, false),
+		Qt(-0.1, 10, 1, true, false),
+	} {
+		if !math.IsNaN(got) {
+			t.Fatalf("got %v, wanted NaN", got)
+		}
+	}
+}
+
+func TestQuantileBounds(t *testing.T) {
+
+	inf := math.Inf(1)
+	pnorm := func(q float64, lowerTail bool, logP bool) float64 { return Pnorm(q, 0, 1, lowerTail, logP) }
+	qnorm := func(p float64, lowerTail bool, logP bool) float64 { return Qnorm(p, 0, 1, lowerTail, logP) }
+
+	// the quantiles of probabilities 0 and 1 are the ends of the support,
+	// as in R
+	cases := []struct {
+		name     string
+		quantile func(p float64, lowerTail bool, logP bool) float64
+		min      float64
+		max      float64
+	}{
+		{"qnorm", qnorm, -inf, inf},
+		{"qunif", func(p float64, lowerTail bool, logP bool) float64 { return Qunif(p, -1, 2, lowerTail, logP) }, -1, 2},
+		{"qcauchy", func(p float64, lowerTail bool, logP bool) float64 { return Qcauchy(p, 1, 2, lowerTail, logP) }, -inf, inf},
+		{"qbeta", func(p float64, lowerTail bool, logP bool) float64 { return Qbeta(p, 2, 3, lowerTail, logP) }, 0, 1},
+		{"qgamma", func(p float64, lowerTail bool, logP bool) float64 { return Qgamma(p, 2, 3, lowerTail, logP) }, 0, inf},
+		{"qlnorm", func(p float64, lowerTail bool, logP bool) float64 { return Qlnorm(p, 1, 2, lowerTail, logP) }, 0, inf},
+		{"qbinom", func(p float64, lowerTail bool, logP bool) float64 { return Qbinom(p, 10, 0.3, lowerTail, logP) }, 0, 10},
+		{"qt", func(p float64, lowerTail bool, logP bool) float64 {
+			return Qscaled_shifted_t(p, 1, 2, 5, lowerTail, logP)
+		}, -inf, inf},
+		{"qt ncp", func(p float64, lowerTail bool, logP bool) float64 { return Qt(p, 12, 2, lowerTail, logP) }, -inf, inf},
+		{"qtruncated", func(p float64, lowerTail bool, logP bool) float64 {
+			if !lowerTail {
+				p = 1 - p
+			}
+			return Qtruncated(p, pnorm, qnorm, -1, 2)
+		}, -1, 2},
+	}
+	for _, c := range cases {
+		for _, lowerTail := range []bool{true, false} {
+			for _, logP := range []bool{false, true} {
+				if logP && c.name == "qtruncated" {
+					continue
+				}
+				zero, one := 0.0, 1.0
+				if logP {
+					zero, one = -inf, 0
+				}
+				min, max := c.min, c.max
+				if !lowerTail {
+					min, max = max, min
+				}
+				if got := c.quantile(zero, lowerTail, logP); got != min {
+					t.Fatalf("%v(0, lowerTail = %v, logP = %v): got %v, wanted %v", c.name, lowerTail, logP, got, min)
+				}
+				if got := c.quantile(one, lowerTail, logP); got != max {
+					t.Fatalf("%v(1, lowerTail = %v, logP = %v): got %v, wanted %v", c.name, lowerTail, logP, got, max)
+				}
+			}
+		}
+	}
+}
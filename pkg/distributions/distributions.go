@@ -3,9 +3,7 @@ package distributions
 import (
 	"math"
 
-	"gonum.org/v1/gonum/integrate/quad"
 	"gonum.org/v1/gonum/stat/distuv"
-	"scientificgo.org/special"
 )
 
 // consistent interface for statistica distributions
@@ -26,12 +24,13 @@ func findlimits(f func(x float64) float64) float64 {
 	return x //- .2
 }
 
-// This is needed because of errors that sometimes result from
-// integrating t distributions
-
-func Integrate(f func(float64) float64, min float64, max float64) float64 {
-	auc := quad.Fixed(f, min, max, 10000, nil, 100)
-	return auc
+func LogDunif(x float64, min float64, max float64) float64 {
+	dist := distuv.Uniform{
+		Min: min,
+		Max: max,
+		Src: nil,
+	}
+	return dist.LogProb(x)
 }
 
 func Dunif(x float64, min float64, max float64) float64 {
@@ -43,6 +42,15 @@ func Dunif(x float64, min float64, max float64) float64 {
 	return dist.Prob(x)
 }
 
+func LogDbinom(x float64, n float64, p float64) float64 {
+	dist := distuv.Binomial{
+		N:   n,
+		P:   p,
+		Src: nil,
+	}
+	return dist.LogProb(x)
+}
+
 func Dbinom(x float64, n float64, p float64) float64 {
 	dist := distuv.Binomial{
 		N:   n,
@@ -52,6 +60,15 @@ func Dbinom(x float64, n float64, p float64) float64 {
 	return dist.Prob(x)
 }
 
+func LogDbeta(x float64, shape1 float64, shape2 float64) float64 {
+	dist := distuv.Beta{
+		Alpha: shape1,
+		Beta:  shape2,
+		Src:   nil,
+	}
+	return dist.LogProb(x)
+}
+
 func Dbeta(x float64, shape1 float64, shape2 float64) float64 {
 	dist := distuv.Beta{
 		Alpha: shape1,
@@ -61,6 +78,132 @@ func Dbeta(x float64, shape1 float64, shape2 float64) float64 {
 	return dist.Prob(x)
 }
 
+func LogDpois(x float64, lambda float64) float64 {
+	// gonum gives NaN for a rate of 0
+	if lambda == 0 {
+		return math.Log(inrange(x, 0, 0))
+	}
+	dist := distuv.Poisson{
+		Lambda: lambda,
+		Src:    nil,
+	}
+	return dist.LogProb(x)
+}
+
+func Dpois(x float64, lambda float64) float64 {
+	return math.Exp(LogDpois(x, lambda))
+}
+
+// LogDnbinom is the log density of the negative binomial distribution
+// with the mean (mu) parameterization, as in R's dnbinom(x, size, mu = mu)
+func LogDnbinom(x float64, size float64, mu float64) float64 {
+	if !(size > 0) || !(mu >= 0) || math.IsNaN(x) {
+		return math.NaN()
+	}
+	if x < 0 || math.Floor(x) != x {
+		return math.Inf(-1)
+	}
+	if mu == 0 {
+		return math.Log(inrange(x, 0, 0))
+	}
+	lgxs, _ := math.Lgamma(x + size)
+	lgs, _ := math.Lgamma(size)
+	lgx, _ := math.Lgamma(x + 1)
+	return lgxs - lgs - lgx - size*math.Log1p(mu/size) + x*(math.Log(mu)-math.Log(size+mu))
+}
+
+func Dnbinom(x float64, size float64, mu float64) float64 {
+	return math.Exp(LogDnbinom(x, size, mu))
+}
+
+func LogDgamma(x float64, shape float64, rate float64) float64 {
+	dist := distuv.Gamma{
+		Alpha: shape,
+		Beta:  rate,
+		Src:   nil,
+	}
+	return dist.LogProb(x)
+}
+
+func Dgamma(x float64, shape float64, rate float64) float64 {
+	dist := distuv.Gamma{
+		Alpha: shape,
+		Beta:  rate,
+		Src:   nil,
+	}
+	return dist.Prob(x)
+}
+
+func LogDlnorm(x float64, meanlog float64, sdlog float64) float64 {
+	if x <= 0 {
+		return math.Inf(-1)
+	}
+	dist := distuv.LogNormal{
+		Mu:    meanlog,
+		Sigma: sdlog,
+		Src:   nil,
+	}
+	return dist.LogProb(x)
+}
+
+func Dlnorm(x float64, meanlog float64, sdlog float64) float64 {
+	return math.Exp(LogDlnorm(x, meanlog, sdlog))
+}
+
+// LogDcor is the log density of the sample (Pearson) correlation r of n
+// pairs from a bivariate normal distribution with correlation rho
+// (Hotelling, 1953)
+func LogDcor(r float64, rho float64, n float64) float64 {
+	if !(n > 2) || !(rho >= -1 && rho <= 1) || math.IsNaN(r) {
+		return math.NaN()
+	}
+	if !(r > -1 && r < 1) || math.Abs(rho) == 1 {
+		return math.Inf(-1)
+	}
+	lgn1, _ := math.Lgamma(n - 1)
+	lgn5, _ := math.Lgamma(n - 0.5)
+	return math.Log(n-2) + lgn1 - lgn5 - 0.5*math.Log(2*math.Pi) +
+		(n-1)/2*math.Log1p(-rho*rho) + (n-4)/2*math.Log1p(-r*r) -
+		(n-1.5)*math.Log1p(-rho*r) + math.Log(hyp2f1Half(n-0.5, (1+rho*r)/2))
+}
+
+func Dcor(r float64, rho float64, n float64) float64 {
+	return math.Exp(LogDcor(r, rho, n))
+}
+
+// hyp2f1Half is the hypergeometric function 2F1(1/2, 1/2; c; x) for
+// c > 1 and 0 <= x < 1, summed as a power series. The terms shrink at
+// least as fast as x^k, so the sum is slow only for x very close to 1.
+func hyp2f1Half(c float64, x float64) float64 {
+	sum, term := 1.0, 1.0
+	for k := 0.0; k < 1e7; k++ {
+		term *= (k + 0.5) * (k + 0.5) / ((c + k) * (k + 1)) * x
+		sum += term
+		if term < 1e-17*sum*(1-x) {
+			break
+		}
+	}
+	return sum
+}
+
+// inrange is 1 if x is in [min, max] and 0 otherwise
+func inrange(x float64, min float64, max float64) float64 {
+	if x >= min && x <= max {
+		return 1
+	}
+	return 0
+}
+
+func LogScaled_shifted_t(x float64, mean float64, sd float64, df float64) float64 {
+	dist := distuv.StudentsT{
+		Mu:    mean,
+		Sigma: sd,
+		Nu:    df,
+		Src:   nil,
+	}
+	return dist.LogProb(x)
+}
+
 func Scaled_shifted_t(x float64, mean float64, sd float64, df float64) float64 {
 	dist := distuv.StudentsT{
 		Mu:    mean,
@@ -71,6 +214,15 @@ func Scaled_shifted_t(x float64, mean float64, sd float64, df float64) float64 {
 	return dist.Prob(x)
 }
 
+func LogDnorm(x float64, mean float64, sd float64) float64 {
+	dist := distuv.Normal{
+		Mu:    mean,
+		Sigma: sd,
+		Src:   nil,
+	}
+	return dist.LogProb(x)
+}
+
 func Dnorm(x float64, mean float64, sd float64) float64 {
 	dist := distuv.Normal{
 		Mu:    mean,
@@ -81,29 +233,79 @@ func Dnorm(x float64, mean float64, sd float64) float64 {
 }
 
 func Dt(x float64, df float64, ncp float64) float64 {
-	var x2 float64 = x * x
-	var ncx2 float64 = ncp * ncp * x2
-	var fac1 float64 = df + x2
+	return math.Exp(LogDt(x, df, ncp))
+}
+
+// LogDt is the log density of the noncentral t distribution. A noncentral
+// t variable is (Z + ncp) / S where S = sqrt(V / df) and V is chi-squared
+// with df degrees of freedom, so the density at x is the mixture
+//
+//	f(x) = integral over s > 0 of s * dnorm(x * s, ncp, 1) * g(s)
+//
+// where g is the density of S. The log of the integrand is concave in s,
+// so it is integrated on the log scale around its peak, which keeps it
+// finite for large x, df and ncp. If the integration fails the Laplace
+// approximation is used instead.
+func LogDt(x float64, df float64, ncp float64) float64 {
 
-	lgammadf1, _ := math.Lgamma(df + 1)
-	lgamma_halfdf, _ := math.Lgamma(df / 2)
+	if math.IsNaN(x) || math.IsNaN(df) || math.IsNaN(ncp) || !(df > 0) || math.IsInf(ncp, 0) {
+		return math.NaN()
+	}
+	if math.IsInf(x, 0) {
+		return math.Inf(-1)
+	}
+
+	lgammaHalfdf, _ := math.Lgamma(df / 2)
+	constant := df/2*math.Log(df) + (1-df/2)*math.Ln2 - lgammaHalfdf - math.Log(2*math.Pi)/2
+	logIntegrand := func(s float64) float64 {
+		z := x*s - ncp
+		return df*math.Log(s) - df*s*s/2 - z*z/2
+	}
 
-	trm1 := df/2.*math.Log(df) + lgammadf1
-	trm1 -= df*math.Log(2) + ncp*ncp/2 + (df/2)*math.Log(fac1) + lgamma_halfdf
+	// the peak is the positive root of (df + x^2) s^2 - x ncp s - df,
+	// computed without cancellation
+	a := df + x*x
+	b := x * ncp
+	root := math.Sqrt(b*b + 4*df*a)
+	var peak float64
+	if b >= 0 {
+		peak = (b + root) / (2 * a)
+	} else {
+		peak = 2 * df / (root - b)
+	}
+	top := logIntegrand(peak)
 
-	var Px float64 = math.Exp(trm1)
-	var valF float64 = ncx2 / (2 * fac1)
+	// the second derivative is less than -(df + x^2) everywhere so the
+	// integrand is below exp(-800) of its peak outside of this range
+	width := 40 / math.Sqrt(a)
+	min := math.Max(0, peak-width)
+	max := peak + width
 
-	trm1 = math.Sqrt(2) * ncp * x * special.HypPFQ([]float64{df/2 + 1}, []float64{1.5}, valF)
-	trm1 /= fac1 * math.Gamma((df+1)/2)
+	integral, err := tQuadrature.Integrate(func(s float64) float64 {
+		if s <= 0 {
+			return 0
+		}
+		return math.Exp(logIntegrand(s) - top)
+	}, min, max, peak)
 
-	var trm2 float64 = special.HypPFQ([]float64{(df + 1) / 2}, []float64{0.5}, valF)
-	trm2 /= math.Sqrt(fac1) * math.Gamma(df/2+1)
-	Px *= trm1 + trm2
-  if math.IsNaN(Px) {
-    return 0
-  }
-	return Px
+	var logPx float64
+	if err == nil && integral.Value > 0 {
+		logPx = constant + top + math.Log(integral.Value)
+	} else {
+		curvature := df/(peak*peak) + a
+		logPx = constant + top + math.Log(2*math.Pi/curvature)/2
+	}
+	if math.IsNaN(logPx) {
+		return math.Inf(-1)
+	}
+	return logPx
+}
+
+// tQuadrature is the setting used for the mixture integral in LogDt
+var tQuadrature = Quadrature{
+	AbsTol:       0,
+	RelTol:       1e-10,
+	MaxIntervals: 200,
 }
 
 func Dcauchy(x float64, location float64, scale float64) float64 {
@@ -115,3 +317,13 @@ func Dcauchy(x float64, location float64, scale float64) float64 {
 	}
 	return dist.Prob(x)
 }
+
+func LogDcauchy(x float64, location float64, scale float64) float64 {
+	dist := distuv.StudentsT{
+		Mu:    location,
+		Sigma: scale,
+		Nu:    1,
+		Src:   nil,
+	}
+	return dist.LogProb(x)
+}
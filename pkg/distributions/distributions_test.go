@@ -100,3 +100,201 @@ func TestDistribution(t *testing.T) {
 	}
 
 }
+
+func TestLogDistribution(t *testing.T) {
+
+	const tolerance = .0001
+	opt := cmp.Comparer(func(x, y float64) bool {
+		diff := math.Abs(x - y)
+		mean := math.Abs(x+y) / 2.0
+		return (diff / mean) < tolerance
+	})
+
+	// the log densities agree with the densities
+	pairs := [][2]float64{
+		{LogDt(10, 10, 10), math.Log(Dt(10, 10, 10))},
+		{LogDnorm(1, 0.5, 2), math.Log(Dnorm(1, 0.5, 2))},
+		{LogDcauchy(1, 0.5, 2), math.Log(Dcauchy(1, 0.5, 2))},
+		{LogDbeta(.2, 1, 2.5), math.Log(Dbeta(.2, 1, 2.5))},
+		{LogDbinom(3, 12, .6), math.Log(Dbinom(3, 12, .6))},
+		{LogScaled_shifted_t(1, 0.5, 2, 10), math.Log(Scaled_shifted_t(1, 0.5, 2, 10))},
+	}
+	for _, pair := range pairs {
+		if !cmp.Equal(pair[0], pair[1], opt) {
+			t.Fatalf("got %v, wanted %v", pair[0], pair[1])
+		}
+	}
+
+	// and stay finite where the densities underflow
+	got := LogDnorm(40, 0, 1)
+	want := -800.9189385
+
+	if !cmp.Equal(got, want, opt) {
+		t.Fatalf("got %v, wanted %v", got, want)
+	}
+
+	if got := LogDunif(2, 0, 1); !math.IsInf(got, -1) {
+		t.Fatalf("got %v, wanted -Inf", got)
+	}
+}
+
+func TestLogDt(t *testing.T) {
+
+	const tolerance = .0001
+	opt := cmp.Comparer(func(x, y float64) bool {
+		diff := math.Abs(x - y)
+		mean := math.Abs(x+y) / 2.0
+		return (diff / mean) < tolerance
+	})
+
+	// reference values for the R call
+	//
+	//	dt(x, df, ncp, log = TRUE)
+	//
+	// with x, df and ncp the columns of the table. They are computed from
+	// the series for the noncentral t density
+	//
+	//	nu^(nu/2) exp(-ncp^2/2) / (sqrt(pi) gamma(nu/2) (nu + x^2)^((nu+1)/2))
+	//	  * sum_j gamma((nu+j+1)/2) z^j / j!,  z = ncp x sqrt(2 / (nu + x^2))
+	//
+	// summed in 80 digit arithmetic, which gives the R values of Dt in
+	// TestDistribution to all of their printed digits
+	cases := []struct {
+		x, df, ncp float64
+		want       float64
+	}{
+		{2, 1, 1, -1.93935},
+		{-5, 1, 2, -8.226148},
+		{50, 1, 10, -5.767844},
+		{0.5, 1, -3, -7.227556},
+		{1, 2, 0.5, -1.292762},
+		{-1, 2, -2, -1.463814},
+		{10, 2, 8, -2.716871},
+		{-20, 2, 1, -10.88426},
+		{-3, 5, 2, -9.281207},
+		{4, 5, 3, -1.67108},
+		{0, 5, -1, -1.46862},
+		{15, 5, 12, -2.741391},
+		{30, 10, 25, -3.140399},
+		{-2, 10, -4, -2.622038},
+		{1, 10, 0.3, -1.217829},
+		{-8, 10, 2, -19.21865},
+		{-1, 30, 3, -8.840613},
+		{3, 30, 2, -1.451974},
+		{6, 30, -1, -17.59519},
+		{45, 30, 40, -3.073786},
+		{5, 100, 5, -0.9802473},
+		{-3, 100, -3.5, -1.059597},
+		{0, 100, 2, -2.921438},
+		{12, 100, 6, -12.05625},
+		{0, 1000, -2, -2.919189},
+		{40, 1000, 38, -2.334141},
+		{-5, 1000, 1, -18.71163},
+		{2, 1000, 0.1, -2.722534},
+		{2.5, 1e4, 2, -1.044112},
+		{-4, 1e4, 1, -13.40987},
+		{150, 1e4, 148, -2.239869},
+		{-0.5, 1e4, -6, -16.04371},
+		{3, 1e5, 3, -0.9189635},
+		{320, 1e5, 300, -133.7256},
+		{-2, 1e5, 2, -8.918811},
+		{0.1, 1e5, -0.2, -0.9639411},
+	}
+	for _, c := range cases {
+		if got := LogDt(c.x, c.df, c.ncp); !cmp.Equal(got, c.want, opt) {
+			t.Fatalf("LogDt(%v, %v, %v): got %v, wanted %v", c.x, c.df, c.ncp, got, c.want)
+		}
+	}
+
+	// the central t distribution
+	got := Dt(1.5, 7, 0)
+	want := Scaled_shifted_t(1.5, 0, 1, 7)
+	if !cmp.Equal(got, want, opt) {
+		t.Fatalf("got %v, wanted %v", got, want)
+	}
+
+	if got := LogDt(math.Inf(1), 10, 1); !math.IsInf(got, -1) {
+		t.Fatalf("got %v, wanted -Inf", got)
+	}
+}
+
+func TestCountDistributions(t *testing.T) {
+
+	const tolerance = .0001
+	opt := cmp.Comparer(func(x, y float64) bool {
+		diff := math.Abs(x - y)
+		mean := math.Abs(x+y) / 2.0
+		return (diff / mean) < tolerance
+	})
+
+	// reference values are from the closed form densities
+	cases := []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{"dpois", Dpois(3, 2.5), 0.213763},
+		{"dpois zero rate", Dpois(0, 0), 1},
+		{"dnbinom", Dnbinom(4, 2, 3), 0.10368},
+		{"dgamma", Dgamma(1.5, 2, 3), 0.1499715},
+		{"dlnorm", Dlnorm(2, 0.5, 0.8), 0.2421768},
+		{"log dpois", LogDpois(3, 2.5), math.Log(0.213763)},
+		{"log dnbinom", LogDnbinom(4, 2, 3), math.Log(0.10368)},
+		{"log dgamma", LogDgamma(1.5, 2, 3), math.Log(0.1499715)},
+	}
+	for _, c := range cases {
+		if !cmp.Equal(c.got, c.want, opt) {
+			t.Fatalf("%v: got %v, wanted %v", c.name, c.got, c.want)
+		}
+	}
+
+	// a large size gives the poisson distribution
+	if got, want := Dnbinom(4, 1e8, 3), Dpois(4, 3); !cmp.Equal(got, want, opt) {
+		t.Fatalf("got %v, wanted %v", got, want)
+	}
+
+	for _, got := range []float64{Dpois(2, 0), Dpois(1.5, 2), Dnbinom(-1, 2, 3), Dlnorm(-1, 0, 1)} {
+		if got != 0 {
+			t.Fatalf("got %v, wanted 0", got)
+		}
+	}
+}
+
+func TestDcor(t *testing.T) {
+
+	const tolerance = .0001
+	opt := cmp.Comparer(func(x, y float64) bool {
+		diff := math.Abs(x - y)
+		mean := math.Abs(x+y) / 2.0
+		return (diff / mean) < tolerance
+	})
+
+	// reference values are from Hotelling's integral form of the density
+	cases := []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{"no correlation", LogDcor(0.3, 0, 10), -0.1933199},
+		{"positive", LogDcor(0.3, 0.6, 10), -0.5121240},
+		{"small sample", LogDcor(-0.5, 0.2, 5), -1.0140397},
+		{"strong correlation", LogDcor(0.95, 0.9, 30), 1.4328160},
+	}
+	for _, c := range cases {
+		if !cmp.Equal(c.got, c.want, opt) {
+			t.Fatalf("%v: got %v, wanted %v", c.name, c.got, c.want)
+		}
+	}
+
+	// the density integrates to one
+	integral, err := IntegrateAdaptive(func(r float64) float64 { return Dcor(r, 0.6, 10) }, -1, 1)
+	if err != nil || !cmp.Equal(integral.Value, 1.0, opt) {
+		t.Fatalf("got %v and error %v, wanted 1", integral.Value, err)
+	}
+
+	for _, got := range []float64{Dcor(1, 0.5, 10), Dcor(0.5, 1, 10)} {
+		if got != 0 {
+			t.Fatalf("got %v, wanted 0", got)
+		}
+	}
+}
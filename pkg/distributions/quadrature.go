@@ -0,0 +1,324 @@
+package distributions
+
+import (
+	"container/heap"
+	"errors"
+	"math"
+	"sort"
+
+	"gonum.org/v1/gonum/integrate/quad"
+)
+
+// Errors returned by adaptive integration
+var (
+	ErrTolerance = errors.New("integration tolerance not met")
+	ErrIntegrand = errors.New("integrand is not finite")
+)
+
+// Quadrature holds the settings for adaptive Gauss-Kronrod integration.
+// Integration stops when the estimated absolute error is below
+// max(AbsTol, RelTol * |value|) and fails if this takes more than
+// MaxIntervals subintervals.
+type Quadrature struct {
+	AbsTol       float64
+	RelTol       float64
+	MaxIntervals int
+}
+
+// DefaultQuadrature is the setting used by IntegrateAdaptive
+var DefaultQuadrature = Quadrature{
+	AbsTol:       0,
+	RelTol:       1e-8,
+	MaxIntervals: 2000,
+}
+
+// Integral is the result of an adaptive integration
+type Integral struct {
+	Value       float64
+	AbsError    float64
+	Evaluations int
+}
+
+// LogIntegral is the result of an adaptive integration of a function given
+// on the log scale
+type LogIntegral struct {
+	LogValue    float64
+	RelError    float64
+	Evaluations int
+}
+
+// largest difference between the log of the integrand and the shift used
+// by IntegrateLog before the integrand is rescaled
+const maxLogShift = 600
+
+// 15 point Kronrod nodes and weights, with the embedded 7 point Gauss
+// weights (QUADPACK qk15)
+var kronrodNodes = [8]float64{
+	0.991455371120812639206854697526329,
+	0.949107912342758524526189684047851,
+	0.864864423359769072789712788640926,
+	0.741531185599394439863864773280788,
+	0.586087235467691130294144845693013,
+	0.405845151377397166906606412076961,
+	0.207784955007898467600689403773245,
+	0.000000000000000000000000000000000,
+}
+
+var kronrodWeights = [8]float64{
+	0.022935322010529224963732008058970,
+	0.063092092629978553290700663189204,
+	0.104790010322250183839876322541518,
+	0.140653259715525918745189590510238,
+	0.169004726639267902826583426598550,
+	0.190350578064785409913256402421014,
+	0.204432940075298892414161999234649,
+	0.209482141084727828012999174891714,
+}
+
+var gaussWeights = [4]float64{
+	0.129484966168869693270611432679082,
+	0.279705391489276667901467771423780,
+	0.381830050505118944950369775488975,
+	0.417959183673469387755102040816327,
+}
+
+// interval is a subinterval of one of the pieces being integrated with
+// its Kronrod estimate and error
+type interval struct {
+	a, b  float64
+	value float64
+	err   float64
+	piece int
+}
+
+// intervals is a max-heap of intervals ordered by error
+type intervals []interval
+
+func (h intervals) Len() int            { return len(h) }
+func (h intervals) Less(i, j int) bool  { return h[i].err > h[j].err }
+func (h intervals) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *intervals) Push(x interface{}) { *h = append(*h, x.(interval)) }
+func (h *intervals) Pop() interface{} {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+// kronrod applies the 15 point Gauss-Kronrod rule to f on [a, b] and
+// returns the estimate and the difference to the 7 point Gauss rule
+func kronrod(f func(float64) float64, a float64, b float64) interval {
+	center := (a + b) / 2
+	half := (b - a) / 2
+
+	fc := f(center)
+	kronrod := fc * kronrodWeights[7]
+	gauss := fc * gaussWeights[3]
+	for i := 0; i < 7; i++ {
+		dx := half * kronrodNodes[i]
+		sum := f(center-dx) + f(center+dx)
+		kronrod += kronrodWeights[i] * sum
+		if i%2 == 1 {
+			gauss += gaussWeights[i/2] * sum
+		}
+	}
+
+	return interval{
+		a:     a,
+		b:     b,
+		value: kronrod * half,
+		err:   math.Abs((kronrod - gauss) * half),
+	}
+}
+
+// IntegrateAdaptive integrates f from min to max using DefaultQuadrature.
+// See Quadrature.Integrate.
+func IntegrateAdaptive(f func(float64) float64, min float64, max float64, breakpoints ...float64) (Integral, error) {
+	return DefaultQuadrature.Integrate(f, min, max, breakpoints...)
+}
+
+// Integrate integrates f from min to max, either of which can be infinite,
+// using globally adaptive Gauss-Kronrod quadrature. The interval is first
+// split at the breakpoints (those outside of min and max are ignored),
+// which should be used to mark peaks and discontinuities of f. Infinite
+// intervals are mapped onto [0, 1). If the tolerance can't be met the
+// current estimate is returned with ErrTolerance.
+func (q Quadrature) Integrate(f func(float64) float64, min float64, max float64, breakpoints ...float64) (Integral, error) {
+
+	var result Integral
+
+	if math.IsNaN(min) || math.IsNaN(max) {
+		result.Value = math.NaN()
+		return result, ErrIntegrand
+	}
+	if min == max {
+		return result, nil
+	}
+	if min > max {
+		result, err := q.Integrate(f, max, min, breakpoints...)
+		result.Value = -result.Value
+		return result, err
+	}
+
+	// split the range at the breakpoints
+	points := []float64{min}
+	sorted := append([]float64(nil), breakpoints...)
+	sort.Float64s(sorted)
+	for _, x := range sorted {
+		if x > points[len(points)-1] && x < max && !math.IsInf(x, 0) {
+			points = append(points, x)
+		}
+	}
+	if len(points) == 1 && math.IsInf(min, -1) && math.IsInf(max, 1) {
+		points = append(points, 0)
+	}
+	points = append(points, max)
+
+	// each piece is integrated over a finite interval in its own
+	// coordinates, so keep the transformed integrand with the piece
+	type piece struct {
+		f func(float64) float64
+		a float64
+		b float64
+	}
+	pieces := make([]piece, 0, len(points)-1)
+	for i := 1; i < len(points); i++ {
+		a, b := points[i-1], points[i]
+		switch {
+		case math.IsInf(a, -1):
+			pieces = append(pieces, piece{f: func(t float64) float64 {
+				u := 1 - t
+				return f(b-t/u) / (u * u)
+			}, a: 0, b: 1})
+		case math.IsInf(b, 1):
+			pieces = append(pieces, piece{f: func(t float64) float64 {
+				u := 1 - t
+				return f(a+t/u) / (u * u)
+			}, a: 0, b: 1})
+		default:
+			pieces = append(pieces, piece{f: f, a: a, b: b})
+		}
+	}
+
+	h := &intervals{}
+	for i, p := range pieces {
+		iv := kronrod(p.f, p.a, p.b)
+		iv.piece = i
+		result.Evaluations += 15
+		heap.Push(h, iv)
+	}
+
+	total := func() (float64, float64) {
+		var value, err float64
+		for _, iv := range *h {
+			value += iv.value
+			err += iv.err
+		}
+		return value, err
+	}
+
+	for {
+		value, err := total()
+		result.Value, result.AbsError = value, err
+		if math.IsNaN(value) || math.IsInf(value, 0) || math.IsNaN(err) {
+			return result, ErrIntegrand
+		}
+		if err <= math.Max(q.AbsTol, q.RelTol*math.Abs(value)) {
+			return result, nil
+		}
+		if h.Len() >= q.MaxIntervals {
+			return result, ErrTolerance
+		}
+
+		// bisect the interval with the largest error
+		worst := heap.Pop(h).(interval)
+		mid := (worst.a + worst.b) / 2
+		if mid <= worst.a || mid >= worst.b {
+			heap.Push(h, worst)
+			return result, ErrTolerance
+		}
+		f := pieces[worst.piece].f
+		for _, iv := range []interval{kronrod(f, worst.a, mid), kronrod(f, mid, worst.b)} {
+			iv.piece = worst.piece
+			result.Evaluations += 15
+			heap.Push(h, iv)
+		}
+	}
+}
+
+// IntegrateLog integrates exp(logf) from min to max and returns the log of
+// the integral. The integrand is scaled by the largest value of logf found
+// at the breakpoints (and, if needed, during integration) so that the
+// integral can be computed even when exp(logf) underflows or overflows.
+func (q Quadrature) IntegrateLog(logf func(float64) float64, min float64, max float64, breakpoints ...float64) (LogIntegral, error) {
+
+	var result LogIntegral
+
+	shift := math.Inf(-1)
+	for _, x := range append([]float64{min, max}, breakpoints...) {
+		if x >= min && x <= max && !math.IsInf(x, 0) {
+			shift = math.Max(shift, logf(x))
+		}
+	}
+	if math.IsInf(shift, 0) || math.IsNaN(shift) {
+		shift = 0
+	}
+
+	for attempt := 0; ; attempt++ {
+		peak := math.Inf(-1)
+		f := func(x float64) float64 {
+			y := logf(x)
+			if y > peak {
+				peak = y
+			}
+			return math.Exp(y - shift)
+		}
+		integral, err := q.Integrate(f, min, max, breakpoints...)
+		result.Evaluations += integral.Evaluations
+
+		// rescale if the integrand overflowed or underflowed
+		if attempt < 2 && !math.IsInf(peak, -1) && math.Abs(peak-shift) > maxLogShift {
+			shift = peak
+			continue
+		}
+
+		result.LogValue = shift + math.Log(integral.Value)
+		if integral.Value > 0 {
+			result.RelError = integral.AbsError / integral.Value
+		}
+		return result, err
+	}
+}
+
+// IntegrateHermite integrates exp(logf) over the real line with an n point
+// Gauss-Hermite rule whose nodes are centered on center and spread by
+// scale, and returns the log of the integral. With the mode of logf as the
+// center and the scale of the Laplace approximation (one over the square
+// root of minus the second derivative of logf at the mode) the rule is
+// exact for gaussian integrands and very accurate for smooth, unimodal
+// ones. Unlike adaptive integration the result changes smoothly with
+// center, scale and logf, so it can be used inside another integral.
+func IntegrateHermite(logf func(float64) float64, center float64, scale float64, n int) float64 {
+
+	nodes := make([]float64, n)
+	weights := make([]float64, n)
+	quad.Hermite{}.FixedLocations(nodes, weights, math.Inf(-1), math.Inf(1))
+
+	// x = center + sqrt(2) scale z, and the rule integrates
+	// exp(logf(x) + z^2) against the weight exp(-z^2)
+	terms := make([]float64, n)
+	shift := math.Inf(-1)
+	for i, z := range nodes {
+		terms[i] = math.Log(weights[i]) + logf(center+math.Sqrt2*scale*z) + z*z
+		shift = math.Max(shift, terms[i])
+	}
+	if math.IsInf(shift, 0) || math.IsNaN(shift) {
+		return shift
+	}
+
+	var sum float64
+	for _, term := range terms {
+		sum += math.Exp(term - shift)
+	}
+	return shift + math.Log(sum) + math.Log(math.Sqrt2*scale)
+}
@@ -0,0 +1,100 @@
+package distributions
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestIntegrateAdaptive(t *testing.T) {
+
+	tests := []struct {
+		name        string
+		f           func(float64) float64
+		min, max    float64
+		breakpoints []float64
+		want        float64
+	}{
+		{"normal", func(x float64) float64 { return Dnorm(x, 0, 1) }, math.Inf(-1), math.Inf(1), nil, 1},
+		{"half normal", func(x float64) float64 { return Dnorm(x, 0, 1) }, 0, math.Inf(1), nil, 0.5},
+		{"lower tail", func(x float64) float64 { return Dnorm(x, 0, 1) }, math.Inf(-1), -1.959964, nil, 0.025},
+		{"polynomial", func(x float64) float64 { return x * x }, 0, 1, nil, 1.0 / 3},
+		{"reversed", func(x float64) float64 { return x * x }, 1, 0, nil, -1.0 / 3},
+		{"singular", func(x float64) float64 { return 1 / math.Sqrt(x) }, 0, 1, nil, 2},
+		{"cauchy", func(x float64) float64 { return Dcauchy(x, 0, 1) }, math.Inf(-1), math.Inf(1), nil, 1},
+		{"narrow peak", func(x float64) float64 { return Dnorm(x, 1000, 0.001) }, math.Inf(-1), math.Inf(1), []float64{1000}, 1},
+	}
+
+	for _, test := range tests {
+		got, err := IntegrateAdaptive(test.f, test.min, test.max, test.breakpoints...)
+		if err != nil {
+			t.Fatalf("%v: got error %v", test.name, err)
+		}
+		if math.Abs(got.Value-test.want) > 1e-6 {
+			t.Fatalf("%v: got %v, wanted %v", test.name, got.Value, test.want)
+		}
+		if got.AbsError > 1e-6 || got.Evaluations == 0 {
+			t.Fatalf("%v: got error estimate %v after %v evaluations", test.name, got.AbsError, got.Evaluations)
+		}
+	}
+
+	// the tolerance can't be met with a single interval
+	q := Quadrature{RelTol: 1e-10, MaxIntervals: 1}
+	_, err := q.Integrate(func(x float64) float64 { return math.Sin(100 * x) }, 0, 10)
+	if !errors.Is(err, ErrTolerance) {
+		t.Fatalf("got error %v, wanted %v", err, ErrTolerance)
+	}
+
+	_, err = IntegrateAdaptive(func(x float64) float64 { return math.NaN() }, 0, 1)
+	if !errors.Is(err, ErrIntegrand) {
+		t.Fatalf("got error %v, wanted %v", err, ErrIntegrand)
+	}
+}
+
+func TestIntegrateLog(t *testing.T) {
+
+	// integrands that overflow and underflow
+	for _, shift := range []float64{0, 1000, -1000, 5000} {
+		logf := func(x float64) float64 { return LogDnorm(x, 2, 0.01) + shift }
+		got, err := DefaultQuadrature.IntegrateLog(logf, math.Inf(-1), math.Inf(1), 2)
+		if err != nil {
+			t.Fatalf("shift %v: got error %v", shift, err)
+		}
+		if math.Abs(got.LogValue-shift) > 1e-6 || got.RelError > 1e-6 {
+			t.Fatalf("shift %v: got %v with relative error %v", shift, got.LogValue, got.RelError)
+		}
+	}
+
+	// the peak isn't at a breakpoint
+	logf := func(x float64) float64 { return LogDnorm(x, 0.5, 0.2) - 2000 }
+	got, err := DefaultQuadrature.IntegrateLog(logf, 0, 1)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	if math.Abs(got.LogValue-(-2000+math.Log(0.9875807))) > 1e-6 {
+		t.Fatalf("got %v", got.LogValue)
+	}
+}
+
+func TestIntegrateHermite(t *testing.T) {
+
+	// gaussian integrands are integrated exactly, far from the center and
+	// far into the tails
+	logf := func(x float64) float64 { return LogDnorm(x, 2, 0.01) + 1000 }
+	if got := IntegrateHermite(logf, 2, 0.01, 20); math.Abs(got-1000) > 1e-10 {
+		t.Fatalf("got %v, wanted 1000", got)
+	}
+	if got := IntegrateHermite(logf, 2.005, 0.012, 20); math.Abs(got-1000) > 1e-8 {
+		t.Fatalf("got %v, wanted 1000", got)
+	}
+
+	// a skewed integrand: the log of a beta(3, 8) density on the logit
+	// scale, whose integral is 1
+	logf = func(x float64) float64 {
+		p := 1 / (1 + math.Exp(-x))
+		return LogDbeta(p, 3, 8) + math.Log(p*(1-p))
+	}
+	if got := IntegrateHermite(logf, math.Log(3.0/8), math.Sqrt(1/3.0+1/8.0), 20); math.Abs(got) > 1e-6 {
+		t.Fatalf("got %v, wanted 0", got)
+	}
+}
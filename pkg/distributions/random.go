@@ -0,0 +1,153 @@
+package distributions
+
+import (
+	"math"
+
+	"golang.org/x/exp/rand"
+	"gonum.org/v1/gonum/stat/distuv"
+)
+
+// Random variate (R*) functions. Each draws a single value using src so
+// that simulations can be reproduced from a seed. Invalid parameters give
+// NaN.
+
+func Runif(min float64, max float64, src rand.Source) float64 {
+	if !(min <= max) || math.IsInf(min, 0) || math.IsInf(max, 0) {
+		return math.NaN()
+	}
+	dist := distuv.Uniform{
+		Min: min,
+		Max: max,
+		Src: src,
+	}
+	return dist.Rand()
+}
+
+func Rnorm(mean float64, sd float64, src rand.Source) float64 {
+	if !(sd >= 0) || math.IsInf(sd, 0) || math.IsNaN(mean) {
+		return math.NaN()
+	}
+	dist := distuv.Normal{
+		Mu:    mean,
+		Sigma: sd,
+		Src:   src,
+	}
+	return dist.Rand()
+}
+
+func Rcauchy(location float64, scale float64, src rand.Source) float64 {
+	if !(scale > 0) || math.IsInf(scale, 0) || math.IsNaN(location) {
+		return math.NaN()
+	}
+	dist := distuv.StudentsT{
+		Mu:    location,
+		Sigma: scale,
+		Nu:    1,
+		Src:   src,
+	}
+	return dist.Rand()
+}
+
+func Rscaled_shifted_t(mean float64, sd float64, df float64, src rand.Source) float64 {
+	if !(sd > 0) || !(df > 0) || math.IsInf(sd, 0) || math.IsNaN(mean) {
+		return math.NaN()
+	}
+	dist := distuv.StudentsT{
+		Mu:    mean,
+		Sigma: sd,
+		Nu:    df,
+		Src:   src,
+	}
+	return dist.Rand()
+}
+
+// Rt draws from the noncentral t distribution as (Z + ncp) / sqrt(V / df)
+// where Z is standard normal and V is chi-squared with df degrees of
+// freedom
+func Rt(df float64, ncp float64, src rand.Source) float64 {
+	if !(df > 0) || math.IsNaN(ncp) || math.IsInf(ncp, 0) {
+		return math.NaN()
+	}
+	z := distuv.Normal{Mu: 0, Sigma: 1, Src: src}.Rand()
+	v := distuv.ChiSquared{K: df, Src: src}.Rand()
+	return (z + ncp) / math.Sqrt(v/df)
+}
+
+func Rbeta(shape1 float64, shape2 float64, src rand.Source) float64 {
+	if !(shape1 > 0) || !(shape2 > 0) || math.IsInf(shape1, 0) || math.IsInf(shape2, 0) {
+		return math.NaN()
+	}
+	dist := distuv.Beta{
+		Alpha: shape1,
+		Beta:  shape2,
+		Src:   src,
+	}
+	return dist.Rand()
+}
+
+func Rbinom(n float64, p float64, src rand.Source) float64 {
+	if !validBinomial(n, p) {
+		return math.NaN()
+	}
+	dist := distuv.Binomial{
+		N:   n,
+		P:   p,
+		Src: src,
+	}
+	return dist.Rand()
+}
+
+func Rgamma(shape float64, rate float64, src rand.Source) float64 {
+	if !(shape > 0) || !(rate > 0) || math.IsInf(shape, 0) || math.IsInf(rate, 0) {
+		return math.NaN()
+	}
+	dist := distuv.Gamma{
+		Alpha: shape,
+		Beta:  rate,
+		Src:   src,
+	}
+	return dist.Rand()
+}
+
+func Rlnorm(meanlog float64, sdlog float64, src rand.Source) float64 {
+	return math.Exp(Rnorm(meanlog, sdlog, src))
+}
+
+func Rpois(lambda float64, src rand.Source) float64 {
+	if !(lambda >= 0) || math.IsInf(lambda, 0) {
+		return math.NaN()
+	}
+	if lambda == 0 {
+		return 0
+	}
+	dist := distuv.Poisson{
+		Lambda: lambda,
+		Src:    src,
+	}
+	return dist.Rand()
+}
+
+// Rnbinom draws from the negative binomial distribution with the mean
+// parameterization as a gamma mixture of poisson distributions
+func Rnbinom(size float64, mu float64, src rand.Source) float64 {
+	if !(size > 0) || !(mu >= 0) || math.IsInf(size, 0) || math.IsInf(mu, 0) {
+		return math.NaN()
+	}
+	if mu == 0 {
+		return 0
+	}
+	return Rpois(Rgamma(size, size/mu, src), src)
+}
+
+// Rtruncated draws from a distribution truncated to [min, max] by
+// inverting its CDF with Qtruncated
+func Rtruncated(
+	cdf func(q float64, lowerTail bool, logP bool) float64,
+	quantile func(p float64, lowerTail bool, logP bool) float64,
+	min float64,
+	max float64,
+	src rand.Source,
+) float64 {
+	u := distuv.Uniform{Min: 0, Max: 1, Src: src}.Rand()
+	return Qtruncated(u, cdf, quantile, min, max)
+}
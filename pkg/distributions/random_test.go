@@ -0,0 +1,107 @@
+package distributions
+
+import (
+	"math"
+	"testing"
+
+	"golang.org/x/exp/rand"
+)
+
+// mean and standard deviation of n draws
+func moments(n int, draw func() float64) (float64, float64) {
+	var sum, sum2 float64
+	for i := 0; i < n; i++ {
+		x := draw()
+		sum += x
+		sum2 += x * x
+	}
+	mean := sum / float64(n)
+	return mean, math.Sqrt(sum2/float64(n) - mean*mean)
+}
+
+func TestRandom(t *testing.T) {
+
+	const n = 20000
+	src := rand.NewSource(1)
+
+	// the sample moments are within a few standard errors of the true
+	// moments
+	cases := []struct {
+		name     string
+		draw     func() float64
+		mean, sd float64
+	}{
+		{"runif", func() float64 { return Runif(1, 3, src) }, 2, 2 / math.Sqrt(12)},
+		{"rnorm", func() float64 { return Rnorm(1, 2, src) }, 1, 2},
+		{"rt", func() float64 { return Rscaled_shifted_t(1, 2, 10, src) }, 1, 2 * math.Sqrt(10.0/8)},
+		{"rt ncp", func() float64 { return Rt(50, 2, src) }, 2.030638, 1.041557},
+		{"rbeta", func() float64 { return Rbeta(2, 3, src) }, 0.4, 0.2},
+		{"rbinom", func() float64 { return Rbinom(10, 0.3, src) }, 3, math.Sqrt(2.1)},
+		{"rgamma", func() float64 { return Rgamma(3, 1.5, src) }, 2, math.Sqrt(3) / 1.5},
+		{"rlnorm", func() float64 { return Rlnorm(0, 0.5, src) }, math.Exp(0.125), math.Sqrt((math.Exp(0.25) - 1) * math.Exp(0.25))},
+		{"rpois", func() float64 { return Rpois(4, src) }, 4, 2},
+		{"rnbinom", func() float64 { return Rnbinom(2, 3, src) }, 3, math.Sqrt(3 + 9.0/2)},
+	}
+	for _, c := range cases {
+		mean, sd := moments(n, c.draw)
+		if math.Abs(mean-c.mean) > 4*c.sd/math.Sqrt(n) || math.Abs(sd-c.sd) > 0.05*c.sd {
+			t.Fatalf("%v: got mean %v and sd %v, wanted %v and %v", c.name, mean, sd, c.mean, c.sd)
+		}
+	}
+
+	// the median of the cauchy distribution
+	below := 0
+	for i := 0; i < n; i++ {
+		if Rcauchy(1, 2, src) < 1 {
+			below++
+		}
+	}
+	if math.Abs(float64(below)/n-0.5) > 0.02 {
+		t.Fatalf("got %v of %v draws below the median", below, n)
+	}
+
+	// the same seed gives the same draws
+	a, b := rand.NewSource(7), rand.NewSource(7)
+	for i := 0; i < 10; i++ {
+		if x, y := Rt(5, 1, a), Rt(5, 1, b); x != y {
+			t.Fatalf("got %v and %v from the same seed", x, y)
+		}
+	}
+
+	for _, got := range []float64{Rnorm(0, -1, src), Rbeta(0, 1, src), Rbinom(10, 1.5, src), Rt(0, 1, src), Rgamma(1, 0, src), Rpois(-1, src), Rnbinom(0, 1, src)} {
+		if !math.IsNaN(got) {
+			t.Fatalf("got %v, wanted NaN", got)
+		}
+	}
+}
+
+func TestRtruncated(t *testing.T) {
+
+	src := rand.NewSource(1)
+	cdf := func(q float64, lowerTail bool, logP bool) float64 { return Pnorm(q, 0, 1, lowerTail, logP) }
+	quantile := func(p float64, lowerTail bool, logP bool) float64 { return Qnorm(p, 0, 1, lowerTail, logP) }
+
+	// the mean of a truncated standard normal is
+	// (dnorm(a) - dnorm(b)) / (pnorm(b) - pnorm(a))
+	cases := []struct {
+		min, max float64
+		mean     float64
+	}{
+		{0, math.Inf(1), math.Sqrt(2 / math.Pi)},
+		{math.Inf(-1), -1, -1.525135},
+		{-1, 2, 0.2296372},
+		{40, math.Inf(1), 40.02497},
+	}
+	for _, c := range cases {
+		mean, _ := moments(5000, func() float64 {
+			x := Rtruncated(cdf, quantile, c.min, c.max, src)
+			if x < c.min || x > c.max {
+				t.Fatalf("got %v outside of [%v, %v]", x, c.min, c.max)
+			}
+			return x
+		})
+		if math.Abs(mean-c.mean) > 0.03 {
+			t.Fatalf("got mean %v, wanted %v", mean, c.mean)
+		}
+	}
+}